@@ -2,10 +2,62 @@ package v1beta1
 
 import (
 	"fmt"
+	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// MergeTargetConditionTypeConflict is the type of the condition reporting
+	// conflicts over the ownership of the target ConfigMap.
+	MergeTargetConditionTypeConflict = "cmmc/Conflict"
+
+	// MergeTargetConditionTypeTargetDeleted is the type of the condition
+	// reporting that the target ConfigMap was deleted while it was managed.
+	MergeTargetConditionTypeTargetDeleted = "cmmc/TargetDeleted"
+
+	// MergeTargetConditionTypeRebaseline is the type of the condition
+	// reporting the result of the last re-baseline request.
+	MergeTargetConditionTypeRebaseline = "cmmc/Rebaseline"
+
+	// MergeTargetConditionTypeDrifted is the type of the condition reporting
+	// managed keys that were modified by someone else.
+	MergeTargetConditionTypeDrifted = "cmmc/Drifted"
+
+	// MergeTargetConditionTypeSuspended is the type of the condition
+	// reporting that the MergeTarget is suspended.
+	MergeTargetConditionTypeSuspended = "cmmc/Suspended"
+
+	// MergeTargetConditionTypeDryRun is the type of the condition reporting
+	// the result of the last dry run.
+	MergeTargetConditionTypeDryRun = "cmmc/DryRun"
+
+	// MergeTargetConditionTypeRollback is the type of the condition reporting
+	// that the target ConfigMap is pinned to a previous revision.
+	MergeTargetConditionTypeRollback = "cmmc/Rollback"
+
+	// MergeTargetConditionTypeBlocked is the type of the condition reporting
+	// keys held at their previous value by the removal guard.
+	MergeTargetConditionTypeBlocked = "cmmc/Blocked"
+
+	// MergeTargetConditionTypeRequiredEntryMissing is the type of the
+	// condition reporting merged values refused for missing required entries.
+	MergeTargetConditionTypeRequiredEntryMissing = "cmmc/RequiredEntryMissing"
+
+	// MergeTargetConditionTypeSchemaWarning is the type of the condition
+	// reporting keys that don't validate, without being blocked.
+	MergeTargetConditionTypeSchemaWarning = "cmmc/SchemaWarning"
+
+	// MergeTargetConditionTypeSchemaViolated is the type of the condition
+	// reporting that the data of the target ConfigMap doesn't validate
+	// against the schema of the MergeTarget.
+	MergeTargetConditionTypeSchemaViolated = "cmmc/SchemaViolated"
+
+	// MergeSourceConditionTypeSuspended is the type of the condition
+	// reporting that the MergeSource is suspended.
+	MergeSourceConditionTypeSuspended = "cmmc/Suspended"
+)
+
 func MergeSourceConditionReady(numSources int) metav1.Condition {
 	return metav1.Condition{
 		Type:    "Ready",
@@ -15,6 +67,15 @@ func MergeSourceConditionReady(numSources int) metav1.Condition {
 	}
 }
 
+func MergeSourceConditionSuspended() metav1.Condition {
+	return metav1.Condition{
+		Type:    MergeSourceConditionTypeSuspended,
+		Status:  metav1.ConditionTrue,
+		Reason:  "suspended",
+		Message: "Not reconciling, the target keys it contributes to are frozen",
+	}
+}
+
 func MergeTargetConditionValidationErrors(numSources int, errors []string) metav1.Condition {
 	return metav1.Condition{
 		Type:    "cmmc/Validation",
@@ -84,3 +145,184 @@ func MergeTargetConditionValidation(errors []string, numSources int) metav1.Cond
 
 	return MergeTargetConditionNoValidationErrors(numSources)
 }
+
+func MergeTargetConditionFieldManagerConflict(owners []string, conflicts string) metav1.Condition {
+	return metav1.Condition{
+		Type:    MergeTargetConditionTypeConflict,
+		Status:  metav1.ConditionTrue,
+		Reason:  "fieldManagerConflict",
+		Message: fmt.Sprintf("Fields of the target ConfigMap were changed by %s: %s", strings.Join(owners, ", "), conflicts),
+	}
+}
+
+func MergeTargetConditionKeyConflict(key, owner string) metav1.Condition {
+	return metav1.Condition{
+		Type:    MergeTargetConditionTypeConflict,
+		Status:  metav1.ConditionTrue,
+		Reason:  "keyManagedByOtherTarget",
+		Message: fmt.Sprintf("Key %s of the target ConfigMap is managed by MergeTarget %s", key, owner),
+	}
+}
+
+func MergeTargetConditionAwaitingHandover(key, owner string) metav1.Condition {
+	return metav1.Condition{
+		Type:    MergeTargetConditionTypeConflict,
+		Status:  metav1.ConditionTrue,
+		Reason:  "awaitingHandover",
+		Message: fmt.Sprintf("Key %s of the target ConfigMap is managed by MergeTarget %s, waiting for it to hand it over", key, owner),
+	}
+}
+
+func MergeTargetConditionConflicting(err error) metav1.Condition {
+	return metav1.Condition{
+		Type:    "Ready",
+		Status:  metav1.ConditionFalse,
+		Reason:  "conflict",
+		Message: fmt.Sprintf("Not managing the target ConfigMap: %s", err.Error()),
+	}
+}
+
+func MergeTargetConditionTargetDeleted(target string, policy RecreatePolicy, generation int64) metav1.Condition {
+	return metav1.Condition{
+		Type:               MergeTargetConditionTypeTargetDeleted,
+		Status:             metav1.ConditionTrue,
+		Reason:             "targetDeleted",
+		Message:            fmt.Sprintf("Target ConfigMap %s was deleted while it was managed (recreatePolicy: %s)", target, policy),
+		ObservedGeneration: generation,
+	}
+}
+
+func MergeTargetConditionWaitingForTarget(target string) metav1.Condition {
+	return metav1.Condition{
+		Type:    "Ready",
+		Status:  metav1.ConditionFalse,
+		Reason:  "waitingForTarget",
+		Message: fmt.Sprintf("Waiting for target ConfigMap %s to be created", target),
+	}
+}
+
+func MergeTargetConditionInitFromMissing(err error) metav1.Condition {
+	return metav1.Condition{
+		Type:    "Ready",
+		Status:  metav1.ConditionFalse,
+		Reason:  "initFromMissing",
+		Message: fmt.Sprintf("Failed loading initial values: %s", err.Error()),
+	}
+}
+
+func MergeTargetConditionRebaselined(keys []string) metav1.Condition {
+	return metav1.Condition{
+		Type:    MergeTargetConditionTypeRebaseline,
+		Status:  metav1.ConditionTrue,
+		Reason:  "rebaselined",
+		Message: fmt.Sprintf("Initial values of %s set to their current values without the contributions", keys),
+	}
+}
+
+func MergeTargetConditionRebaselineRefused(keys []string) metav1.Condition {
+	return metav1.Condition{
+		Type:    MergeTargetConditionTypeRebaseline,
+		Status:  metav1.ConditionFalse,
+		Reason:  "notDecomposable",
+		Message: fmt.Sprintf("Current values of %s don't end with the contributions, nothing was re-baselined", keys),
+	}
+}
+
+func MergeTargetConditionDrifted(policy DriftPolicy, drifts string) metav1.Condition {
+	var reason, message string
+	switch policy {
+	case DriftPolicyAlertOnly:
+		reason, message = "driftKept", "kept until the merged data changes"
+	case DriftPolicyPreserve:
+		reason, message = "driftPreserved", "not writing the target ConfigMap until acknowledged"
+	default:
+		reason, message = "driftReverted", "reverted"
+	}
+
+	return metav1.Condition{
+		Type:    MergeTargetConditionTypeDrifted,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: fmt.Sprintf("Managed keys modified by someone else (%s): %s", message, drifts),
+	}
+}
+
+func MergeTargetConditionSuspended() metav1.Condition {
+	return metav1.Condition{
+		Type:    MergeTargetConditionTypeSuspended,
+		Status:  metav1.ConditionTrue,
+		Reason:  "suspended",
+		Message: "Not reconciling, the target ConfigMap is left as it is",
+	}
+}
+
+func MergeTargetConditionDryRun(configMap string, changedKeys []string) metav1.Condition {
+	message := fmt.Sprintf("Target ConfigMap unchanged, would-be data written to %s", configMap)
+	if len(changedKeys) > 0 {
+		message = fmt.Sprintf("Would change %s, would-be data and diff written to %s", changedKeys, configMap)
+	}
+
+	return metav1.Condition{
+		Type:    MergeTargetConditionTypeDryRun,
+		Status:  metav1.ConditionTrue,
+		Reason:  "dryRun",
+		Message: message,
+	}
+}
+
+func MergeTargetConditionRolledBack(revision int64) metav1.Condition {
+	return metav1.Condition{
+		Type:    MergeTargetConditionTypeRollback,
+		Status:  metav1.ConditionTrue,
+		Reason:  "rolledBack",
+		Message: fmt.Sprintf("Target ConfigMap pinned to revision %d until spec.rollbackTo is cleared", revision),
+	}
+}
+
+func MergeTargetConditionRollbackFailed(revision int64, err error) metav1.Condition {
+	return metav1.Condition{
+		Type:    MergeTargetConditionTypeRollback,
+		Status:  metav1.ConditionFalse,
+		Reason:  "rollbackFailed",
+		Message: fmt.Sprintf("Not rolling back to revision %d: %s", revision, err.Error()),
+	}
+}
+
+func MergeTargetConditionBlocked(removals []string) metav1.Condition {
+	return metav1.Condition{
+		Type:   MergeTargetConditionTypeBlocked,
+		Status: metav1.ConditionTrue,
+		Reason: "tooManyRemovals",
+		Message: fmt.Sprintf(
+			"Holding the previous value of keys losing too many entries at once (%s), until acknowledged",
+			strings.Join(removals, ", "),
+		),
+	}
+}
+
+func MergeTargetConditionRequiredEntryMissing(missing []string) metav1.Condition {
+	return metav1.Condition{
+		Type:    MergeTargetConditionTypeRequiredEntryMissing,
+		Status:  metav1.ConditionTrue,
+		Reason:  "requiredEntryMissing",
+		Message: fmt.Sprintf("Not writing merged values missing required entries: %s", strings.Join(missing, "; ")),
+	}
+}
+
+func MergeTargetConditionSchemaWarning(warnings []string) metav1.Condition {
+	return metav1.Condition{
+		Type:    MergeTargetConditionTypeSchemaWarning,
+		Status:  metav1.ConditionTrue,
+		Reason:  "schemaViolations",
+		Message: fmt.Sprintf("Keys not validating (not enforced): %s", strings.Join(warnings, "; ")),
+	}
+}
+
+func MergeTargetConditionSchemaViolated(err error) metav1.Condition {
+	return metav1.Condition{
+		Type:    MergeTargetConditionTypeSchemaViolated,
+		Status:  metav1.ConditionTrue,
+		Reason:  "schemaViolated",
+		Message: fmt.Sprintf("Not writing the target ConfigMap, its data doesn't validate: %s", err),
+	}
+}
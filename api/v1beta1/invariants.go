@@ -0,0 +1,148 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cashapp/cmmc/util/validator"
+)
+
+// foundValue is a value found at a MergeTargetDataPath, with the pointer to it
+// in the whole data.
+type foundValue struct {
+	pointer string
+	value   string
+}
+
+// checkInvariants checks the invariants of the MergeTarget against the parsed
+// data of the target ConfigMap, and returns their violations, with pointers
+// into the whole data.
+func (m *MergeTarget) checkInvariants(data map[string]interface{}) []validator.Violation {
+	var violations []validator.Violation
+	for _, inv := range m.Spec.Invariants {
+		seen := map[string]string{}
+		for _, p := range inv.Unique {
+			for _, v := range p.find(data) {
+				if first, ok := seen[v.value]; ok {
+					violations = append(violations, validator.Violation{
+						Pointer: v.pointer,
+						Rule:    "unique",
+						Message: fmt.Sprintf("%s: %s is already at %s", inv.Name, v.value, first),
+					})
+				} else {
+					seen[v.value] = v.pointer
+				}
+			}
+		}
+
+		if inv.References == nil {
+			continue
+		}
+
+		defined := map[string]bool{}
+		for _, p := range inv.References.Defined {
+			for _, v := range p.find(data) {
+				defined[v.value] = true
+			}
+		}
+
+		for _, p := range inv.References.Values {
+			for _, v := range p.find(data) {
+				if !defined[v.value] {
+					violations = append(violations, validator.Violation{
+						Pointer: v.pointer,
+						Rule:    "references",
+						Message: fmt.Sprintf("%s: %s is not defined", inv.Name, v.value),
+					})
+				}
+			}
+		}
+	}
+
+	return violations
+}
+
+// find returns the scalar values found at the path, in order.
+func (p MergeTargetDataPath) find(data map[string]interface{}) []foundValue {
+	v, ok := data[p.Key]
+	if !ok {
+		return nil
+	}
+
+	var segments []string
+	if path := strings.TrimPrefix(p.Path, "/"); path != "" {
+		segments = strings.Split(path, "/")
+	}
+
+	var found []foundValue
+	findValues(v, segments, "/"+escapePointer(p.Key), &found)
+	return found
+}
+
+func findValues(v interface{}, segments []string, pointer string, found *[]foundValue) {
+	if len(segments) == 0 {
+		switch v := v.(type) {
+		case string:
+			*found = append(*found, foundValue{pointer: pointer, value: v})
+		case float64, bool, json.Number:
+			*found = append(*found, foundValue{pointer: pointer, value: fmt.Sprint(v)})
+		}
+
+		return
+	}
+
+	segment, rest := unescapePointer(segments[0]), segments[1:]
+	switch v := v.(type) {
+	case []interface{}:
+		for i, item := range v {
+			if segment == "*" || segment == strconv.Itoa(i) {
+				findValues(item, rest, pointer+"/"+strconv.Itoa(i), found)
+			}
+		}
+	case map[string]interface{}:
+		if segment != "*" {
+			if item, ok := v[segment]; ok {
+				findValues(item, rest, pointer+"/"+escapePointer(segment), found)
+			}
+
+			return
+		}
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+		for _, k := range keys {
+			findValues(v[k], rest, pointer+"/"+escapePointer(k), found)
+		}
+	}
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func unescapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
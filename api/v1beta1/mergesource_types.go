@@ -19,6 +19,7 @@ package v1beta1
 import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -61,12 +62,50 @@ type MergeSourceSpec struct {
 
 	// Target is where the aggregated data for this source will be written.
 	Target MergeSourceTargetSpec `json:"target,omitempty"`
+
+	// AnnotateSources opts in to annotating every source ConfigMap with the
+	// MergeSources watching it.
+	//
+	// The controller tracks its sources on its own, so the annotation is purely
+	// informational, and it means writing to ConfigMaps usually owned by someone else.
+	// +optional
+	AnnotateSources bool `json:"annotateSources,omitempty"`
+
+	// DeletionPolicy is what happens to the annotations on the source
+	// ConfigMaps when the MergeSource is deleted, defaults to Revert.
+	//
+	// - Revert removes them.
+	// - Retain keeps them.
+	// +optional
+	// +kubebuilder:default=Revert
+	// +kubebuilder:validation:Enum=Revert;Retain
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Suspend stops reconciling the MergeSource, and freezes its contribution
+	// to the target key as it was when it was suspended.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
 }
 
 // MergeSourceStatus defines the observed state of MergeSource.
 type MergeSourceStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
-	Output     string             `json:"output,omitempty"`
+
+	// Output is the accumulated data of all the source ConfigMaps.
+	//
+	// This is informational only (MergeTargets read the sources directly),
+	// and it is omitted if the controller runs with --merge-source-digest-only.
+	Output string `json:"output,omitempty"`
+
+	// OutputDigest is the sha256 digest of the accumulated data.
+	OutputDigest string `json:"outputDigest,omitempty"`
+
+	// DryRunDigest is the sha256 digest of the source ConfigMaps in dry-run
+	// mode and their data, which isn't part of the output.
+	DryRunDigest string `json:"dryRunDigest,omitempty"`
+
+	// NumSources is the number of source ConfigMaps.
+	NumSources int `json:"numSources,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -75,6 +114,7 @@ type MergeSourceStatus struct {
 // MergeSource is the Schema for the mergesources API.
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description=""
 // +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].message",description=""
+// +kubebuilder:printcolumn:name="Sources",type="integer",JSONPath=".status.numSources",priority=1
 type MergeSource struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -88,6 +128,13 @@ func (m *MergeSource) NamespaceSelector() map[string]string {
 	return m.Spec.NamespaceSelector
 }
 
+// MatchesConfigMap returns true if the spec.selector matches the labels of the ConfigMap.
+//
+// N.B. This does not take the NamespaceSelector into account.
+func (m *MergeSource) MatchesConfigMap(o client.Object) bool {
+	return labels.SelectorFromSet(m.Spec.Selector).Matches(labels.Set(o.GetLabels()))
+}
+
 // NamespacedTargetName gets the types.NamespacedName representation given the
 // namespace of the MergeSource resource.
 func (m *MergeSource) NamespacedTargetName() (types.NamespacedName, error) {
@@ -99,6 +146,11 @@ func (m *MergeSource) SetStatusCondition(c metav1.Condition) {
 	meta.SetStatusCondition(&m.Status.Conditions, c)
 }
 
+// RemoveStatusCondition removes the condition of the given type, if it is set.
+func (m *MergeSource) RemoveStatusCondition(conditionType string) {
+	meta.RemoveStatusCondition(&m.Status.Conditions, conditionType)
+}
+
 func (m *MergeSource) FindStatusCondition(conditionType string) *metav1.Condition {
 	return meta.FindStatusCondition(m.Status.Conditions, conditionType)
 }
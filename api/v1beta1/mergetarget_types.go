@@ -18,12 +18,16 @@ package v1beta1
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/cashapp/cmmc/util"
+	"github.com/cashapp/cmmc/util/entries"
 	"github.com/cashapp/cmmc/util/validator"
 	"github.com/pkg/errors"
 )
@@ -33,12 +37,264 @@ const (
 	DataNewlyCreatedStatusNo  string = "NO"
 )
 
+// RecreatePolicy is what happens when the target ConfigMap is deleted while
+// the MergeTarget is managing it.
+// +kubebuilder:validation:Enum=Recreate;Never
+type RecreatePolicy string
+
+const (
+	// RecreatePolicyRecreate creates the target ConfigMap again.
+	RecreatePolicyRecreate RecreatePolicy = "Recreate"
+
+	// RecreatePolicyNever waits for the target ConfigMap to be created by
+	// someone else, and adopts it.
+	RecreatePolicyNever RecreatePolicy = "Never"
+)
+
+// DriftPolicy is what happens when a key managed by a MergeTarget is
+// modified by someone else.
+// +kubebuilder:validation:Enum=revert;alertOnly;preserve
+type DriftPolicy string
+
+const (
+	// DriftPolicyRevert reports the modification, and reverts it.
+	DriftPolicyRevert DriftPolicy = "revert"
+
+	// DriftPolicyAlertOnly reports the modification, and keeps it until the
+	// value the MergeTarget would write changes.
+	DriftPolicyAlertOnly DriftPolicy = "alertOnly"
+
+	// DriftPolicyPreserve reports the modification, and stops writing the
+	// target ConfigMap until the modification is acknowledged.
+	DriftPolicyPreserve DriftPolicy = "preserve"
+)
+
+// DeletionPolicy is what happens to the changes made by a MergeTarget or a
+// MergeSource when it is deleted.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyRevert reverts all the changes.
+	DeletionPolicyRevert DeletionPolicy = "Revert"
+
+	// DeletionPolicyRetain only removes the annotations, and keeps the data.
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+
+	// DeletionPolicyDelete deletes the target ConfigMap.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+)
+
+// MergeTargetInitFrom refers to a key of a ConfigMap.
+type MergeTargetInitFrom struct {
+	// ConfigMap is the name of the ConfigMap, either namespace/name or just
+	// the name for a ConfigMap in the namespace of the MergeTarget.
+	ConfigMap string `json:"configMap"`
+
+	// Key is the data key of the ConfigMap.
+	Key string `json:"key"`
+}
+
+// InvalidSourcePolicy is what happens to the contribution of a source that
+// doesn't validate on its own.
+// +kubebuilder:validation:Enum=FailKey;Exclude;LastKnownGood
+type InvalidSourcePolicy string
+
+const (
+	// InvalidSourcePolicyFailKey doesn't validate sources on their own, the
+	// key isn't updated if the merged value doesn't validate.
+	InvalidSourcePolicyFailKey InvalidSourcePolicy = "FailKey"
+
+	// InvalidSourcePolicyExclude leaves the invalid contributions out.
+	InvalidSourcePolicyExclude InvalidSourcePolicy = "Exclude"
+
+	// InvalidSourcePolicyLastKnownGood replaces the invalid contributions with
+	// the last valid contribution of the same source ConfigMap, if any.
+	InvalidSourcePolicyLastKnownGood InvalidSourcePolicy = "LastKnownGood"
+)
+
+// SchemaEnforcement is what happens when a key doesn't validate against its schemas.
+// +kubebuilder:validation:Enum=enforce;warn;audit
+type SchemaEnforcement string
+
+const (
+	// SchemaEnforcementEnforce doesn't update keys that don't validate.
+	SchemaEnforcementEnforce SchemaEnforcement = "enforce"
+
+	// SchemaEnforcementWarn updates keys whatever they validate, and only
+	// reports the violations of jsonSchema (and itemSchema).
+	SchemaEnforcementWarn SchemaEnforcement = "warn"
+
+	// SchemaEnforcementAudit enforces jsonSchema (and itemSchema), and also
+	// reports the violations of candidateSchema.
+	SchemaEnforcementAudit SchemaEnforcement = "audit"
+)
+
+// DataFormat is how the value of a key is parsed, to validate it against the
+// schema of the MergeTarget.
+// +kubebuilder:validation:Enum=yaml;text
+type DataFormat string
+
+const (
+	// DataFormatYAML parses the value as a YAML (or JSON) document, values
+	// that aren't valid YAML are strings.
+	DataFormatYAML DataFormat = "yaml"
+
+	// DataFormatText keeps the value as a string.
+	DataFormatText DataFormat = "text"
+)
+
+// SchemaEngine is the JSON schema draft the schemas of a key are compiled with.
+// +kubebuilder:validation:Enum="draft-07";"2019-09";"2020-12"
+type SchemaEngine string
+
+const (
+	// SchemaEngineDraft07 compiles schemas as draft-07.
+	SchemaEngineDraft07 SchemaEngine = "draft-07"
+
+	// SchemaEngine201909 compiles schemas as draft 2019-09.
+	SchemaEngine201909 SchemaEngine = "2019-09"
+
+	// SchemaEngine202012 compiles schemas as draft 2020-12.
+	SchemaEngine202012 SchemaEngine = "2020-12"
+)
+
+// MergeTargetRemovalGuard limits how many entries of a key may disappear in a
+// single update of the target ConfigMap.
+//
+// Entries are the items of a YAML list (e.g. mapRoles of aws-auth), or the
+// lines of any other value.
+type MergeTargetRemovalGuard struct {
+	// MaxRemovedEntries is the maximum number of entries of a key that may be removed at once.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxRemovedEntries *int32 `json:"maxRemovedEntries,omitempty"`
+
+	// MaxRemovedPercent is the maximum percentage of the entries of a key that may be removed at once.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	MaxRemovedPercent *int32 `json:"maxRemovedPercent,omitempty"`
+}
+
+// Blocks returns true if removing that many of the total entries of a key is beyond the limits.
+func (g *MergeTargetRemovalGuard) Blocks(removed, total int) bool {
+	if g == nil || removed == 0 {
+		return false
+	}
+
+	return g.MaxRemovedEntries != nil && removed > int(*g.MaxRemovedEntries) ||
+		g.MaxRemovedPercent != nil && removed*100 > int(*g.MaxRemovedPercent)*total
+}
+
 type MergeTargetDataSpec struct {
 	// +optional
 	Init string `json:"init,omitempty"`
 
+	// InitFrom loads the initial value from a key of another ConfigMap, it
+	// takes precedence over Init, and over the value the key had before the
+	// MergeTarget managed it.
+	// +optional
+	InitFrom *MergeTargetInitFrom `json:"initFrom,omitempty"`
+
 	// +optional
 	JSONSchema string `json:"jsonSchema,omitempty"`
+
+	// RequiredEntries must always be in the merged value, e.g. the node
+	// instance role in mapRoles. A required YAML mapping matches list entries
+	// with at least its fields, anything else matches equal entries (or lines).
+	//
+	// A merged value missing any of them isn't written.
+	// +optional
+	RequiredEntries []string `json:"requiredEntries,omitempty"`
+
+	// ItemSchema is the JSONSchema of every entry of the (YAML list) value,
+	// used to validate each contribution on its own. Without it, contributions
+	// are validated with JSONSchema.
+	// +optional
+	ItemSchema string `json:"itemSchema,omitempty"`
+
+	// InvalidSources is what happens to the contribution of a source that
+	// doesn't validate on its own, defaults to FailKey.
+	// +optional
+	// +kubebuilder:default=FailKey
+	InvalidSources InvalidSourcePolicy `json:"invalidSources,omitempty"`
+
+	// Enforcement is what happens when the key doesn't validate, defaults to enforce.
+	// +optional
+	// +kubebuilder:default=enforce
+	Enforcement SchemaEnforcement `json:"enforcement,omitempty"`
+
+	// CandidateSchema is the JSONSchema the key is audited against, with the
+	// audit enforcement, before it replaces jsonSchema.
+	// +optional
+	CandidateSchema string `json:"candidateSchema,omitempty"`
+
+	// Format is how the value is parsed to validate it against the schema of
+	// the MergeTarget, defaults to yaml.
+	// +optional
+	// +kubebuilder:default=yaml
+	Format DataFormat `json:"format,omitempty"`
+
+	// SchemaEngine is the draft jsonSchema, itemSchema and candidateSchema
+	// are compiled with, defaults to draft-07.
+	// +optional
+	// +kubebuilder:default=draft-07
+	SchemaEngine SchemaEngine `json:"schemaEngine,omitempty"`
+}
+
+// IsolatesSources returns true if contributions are validated on their own.
+func (s *MergeTargetDataSpec) IsolatesSources() bool {
+	return s.InvalidSources == InvalidSourcePolicyExclude || s.InvalidSources == InvalidSourcePolicyLastKnownGood
+}
+
+// ValidateContribution validates the contribution of a single source: every
+// entry with ItemSchema if it is set, otherwise the whole contribution with
+// JSONSchema.
+func (s *MergeTargetDataSpec) ValidateContribution(data string) error {
+	return s.validateContribution(Documents{}, data)
+}
+
+func (s *MergeTargetDataSpec) validateContribution(docs Documents, data string) error {
+	if data == "" || s.ItemSchema == "" && s.JSONSchema == "" {
+		return nil
+	}
+
+	doc, err := docs.parse(data)
+	if err != nil {
+		return err
+	} else if s.ItemSchema == "" {
+		return s.validate(s.JSONSchema, doc)
+	}
+
+	items, ok := doc.Items()
+	if !ok && !doc.IsEmpty() {
+		return errors.New("contribution is not a YAML list")
+	}
+
+	for i, item := range items {
+		if err := s.validate(s.ItemSchema, item); err != nil {
+			var invalid *validator.InvalidContentError
+			if errors.As(err, &invalid) {
+				for j, v := range invalid.Violations {
+					invalid.Violations[j].Pointer = fmt.Sprintf("/%d%s", i, v.Pointer)
+				}
+			}
+
+			return errors.Wrapf(err, "entry %d", i)
+		}
+	}
+
+	return nil
+}
+
+// validate validates the document against one of the schemas of the key, with its engine.
+func (s *MergeTargetDataSpec) validate(schema string, doc *validator.Document) error {
+	v, err := validator.For(validator.Engine(s.SchemaEngine))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(v.Validate(schema, doc))
 }
 
 // MergeTargetDataStatus represents the status of the MergeTarget resource.
@@ -48,6 +304,19 @@ type MergeTargetDataStatus struct {
 
 	// NewlyCreated is "YES" whether or not the MergeTarget created this data key.
 	NewlyCreated string `json:"newlyCreated,omitempty"`
+
+	// AppliedDigest is the digest of the value the MergeTarget last wrote to the key.
+	AppliedDigest string `json:"appliedDigest,omitempty"`
+
+	// DriftedDigest is the digest of the last out-of-band modification of the
+	// key that was reported (and not reverted yet).
+	DriftedDigest string `json:"driftedDigest,omitempty"`
+
+	// LastKnownGoodDigests is the digest of the last valid contribution of each
+	// source ConfigMap (namespace/name), with the LastKnownGood invalid sources
+	// policy. The contributions themselves are kept in a ConfigMap next to the
+	// MergeTarget.
+	LastKnownGoodDigests map[string]string `json:"lastKnownGoodDigests,omitempty"`
 }
 
 // IsStatusNewlyCreated returns true if this field is created by the controller.
@@ -74,15 +343,132 @@ func NewlyCreatedMergeTargetDataStatus(init string) MergeTargetDataStatus {
 	}
 }
 
+// MergeTargetDataPath refers to values inside the (parsed) value of a data key.
+type MergeTargetDataPath struct {
+	// Key is the data key.
+	Key string `json:"key"`
+
+	// Path is a JSON pointer inside the value of the key, in which * matches
+	// every item of a list (or every value of a mapping), e.g. /*/username.
+	// Only strings, numbers and booleans are compared.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// MergeTargetReferences requires the values found at Values to be among the
+// values found at Defined.
+type MergeTargetReferences struct {
+	Values  []MergeTargetDataPath `json:"values"`
+	Defined []MergeTargetDataPath `json:"defined"`
+}
+
+// MergeTargetInvariant is a rule comparing the values of data keys with each
+// other, which a JSON schema can't express.
+type MergeTargetInvariant struct {
+	// Name identifies the invariant in validation errors.
+	Name string `json:"name"`
+
+	// Unique requires every value found at these paths to be found only once,
+	// e.g. a username in both mapRoles and mapUsers.
+	// +optional
+	Unique []MergeTargetDataPath `json:"unique,omitempty"`
+
+	// References requires values to be defined elsewhere, e.g. the upstreams
+	// config.yaml refers to in upstreams.yaml.
+	// +optional
+	References *MergeTargetReferences `json:"references,omitempty"`
+}
+
 // MergeTargetSpec defines the desired state of MergeTarget.
 type MergeTargetSpec struct {
 	// Target refers to the config map we are either creating, or updating.
 	Target string                         `json:"target,omitempty"`
 	Data   map[string]MergeTargetDataSpec `json:"data,omitempty"`
+
+	// Schema is the JSONSchema of the whole data of the target ConfigMap, for
+	// invariants spanning keys (which keys are required or go together, and
+	// rules on a key depending on another one). Values are parsed according to
+	// the format of their key (or as YAML, for keys not managed by the
+	// MergeTarget).
+	//
+	// Data that doesn't validate isn't written at all.
+	// +optional
+	Schema string `json:"schema,omitempty"`
+
+	// SchemaEngine is the draft schema is compiled with, defaults to draft-07.
+	// +optional
+	// +kubebuilder:default=draft-07
+	SchemaEngine SchemaEngine `json:"schemaEngine,omitempty"`
+
+	// Invariants compare the values of data keys with each other, which schema
+	// can't. Data breaking any of them isn't written at all, like data that
+	// doesn't validate against schema.
+	// +optional
+	Invariants []MergeTargetInvariant `json:"invariants,omitempty"`
+
+	// RecreatePolicy is what happens when the target ConfigMap is deleted
+	// while it is managed, defaults to Recreate.
+	// +optional
+	// +kubebuilder:default=Recreate
+	RecreatePolicy RecreatePolicy `json:"recreatePolicy,omitempty"`
+
+	// DeletionPolicy is what happens to the target ConfigMap when the
+	// MergeTarget is deleted (or moves to another target), defaults to Revert.
+	//
+	// - Revert deletes the ConfigMap if the MergeTarget created it, and
+	//   otherwise reverts its keys to their initial values.
+	// - Retain keeps the data, and only removes the annotations.
+	// - Delete deletes the ConfigMap, unless it is shared with other MergeTargets,
+	//   or the MergeTarget neither created it nor manages any of its keys, in
+	//   which case it is reverted.
+	// +optional
+	// +kubebuilder:default=Revert
+	// +kubebuilder:validation:Enum=Revert;Retain;Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// DriftPolicy is what happens when a managed key of the target ConfigMap
+	// is modified by someone else, defaults to revert.
+	// +optional
+	// +kubebuilder:default=revert
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+
+	// Suspend stops reconciling the MergeTarget, leaving the target ConfigMap
+	// as it is. Deleting a suspended MergeTarget still cleans up after it.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// DryRun computes and validates the merged data without writing the target
+	// ConfigMap. The would-be data is written to a <name>-cmmc-dry-run
+	// ConfigMap next to the MergeTarget instead, together with its diff.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// RevisionHistoryLimit is the number of revisions of the data written to
+	// the target ConfigMap that are kept, defaults to 10.
+	// +optional
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=0
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// RollbackTo pins the keys of the target ConfigMap to the data of a
+	// previous revision, until it is cleared.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	RollbackTo *int64 `json:"rollbackTo,omitempty"`
+
+	// RemovalGuard holds the previous value of keys losing too many entries at
+	// once (e.g. when a namespace selector stops matching), until the removal
+	// is acknowledged.
+	// +optional
+	RemovalGuard *MergeTargetRemovalGuard `json:"removalGuard,omitempty"`
 }
 
 // MergeTargetStatus defines the observed state of MergeTarget.
 type MergeTargetStatus struct {
+	// Target is the ConfigMap that is currently managed, which differs from
+	// spec.target while moving to a new one.
+	Target string `json:"target,omitempty"`
+
 	// NewlyCreated means that the resource is fully managing the state of this ConfigMap.
 	//
 	// - empty means that we've never done anything.
@@ -94,6 +480,87 @@ type MergeTargetStatus struct {
 	Data map[string]MergeTargetDataStatus `json:"data,omitempty"`
 
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// HandedOver are the keys that were handed over to another MergeTarget,
+	// and the MergeTarget they were handed over to.
+	//
+	// These keys are not managed anymore, even though they are in the spec.
+	HandedOver map[string]string `json:"handedOver,omitempty"`
+
+	// HandledRequests are the last handled values of the request annotations
+	// (e.g. config.cmmc.k8s.cash.app/rebaseline), by annotation.
+	HandledRequests map[string]string `json:"handledRequests,omitempty"`
+
+	// InputDigest is the digest of the inputs (spec, sources, target ConfigMap)
+	// of the last successful reconciliation.
+	//
+	// If none of them changed, the MergeTarget isn't reconciled again.
+	InputDigest string `json:"inputDigest,omitempty"`
+
+	// CurrentRevision is the revision of the data last written to the target ConfigMap.
+	CurrentRevision int64 `json:"currentRevision,omitempty"`
+
+	// ValidationErrors are the schema violations of the last reconciliation,
+	// attributed to the source they come from (at most MaxValidationErrors).
+	ValidationErrors []MergeTargetValidationError `json:"validationErrors,omitempty"`
+
+	// Contributions is the outcome (accepted, rejected, blocked or duplicated) last
+	// reported to each source ConfigMap, by key and ConfigMap (key/namespace/name).
+	Contributions map[string]string `json:"contributions,omitempty"`
+
+	// SuspendedSources are the contributions of each suspended MergeSource
+	// (namespace/name), as they were when it was suspended.
+	//
+	// They are merged instead of the current data of its source ConfigMaps.
+	SuspendedSources map[string][]SuspendedContribution `json:"suspendedSources,omitempty"`
+}
+
+// SuspendedContribution is the frozen contribution of a source ConfigMap of a
+// suspended MergeSource.
+type SuspendedContribution struct {
+	// Key is the data key of the MergeTarget.
+	Key string `json:"key"`
+
+	// ConfigMap is the source ConfigMap (namespace/name).
+	ConfigMap string `json:"configMap"`
+
+	// Data is its contribution when the MergeSource was suspended.
+	// +optional
+	Data string `json:"data,omitempty"`
+}
+
+// MaxValidationErrors bounds the number of validation errors kept in the status.
+const MaxValidationErrors = 20
+
+// MergeTargetValidationError is a schema rule a data key failed.
+type MergeTargetValidationError struct {
+	// Key is the data key.
+	Key string `json:"key"`
+
+	// MergeSource is the MergeSource (namespace/name) that contributed the
+	// invalid value, if it could be attributed to one.
+	MergeSource string `json:"mergeSource,omitempty"`
+
+	// ConfigMap is the source ConfigMap (namespace/name) that contributed the
+	// invalid value, if it could be attributed to one.
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// Pointer is the JSON pointer of the invalid value, inside the
+	// contribution of the source ConfigMap (or the value of the key).
+	Pointer string `json:"pointer,omitempty"`
+
+	// Rule is the schema rule that failed, e.g. "required" or "pattern".
+	Rule string `json:"rule,omitempty"`
+
+	Message string `json:"message,omitempty"`
+
+	// DryRun is true if the source ConfigMap is in dry-run mode, and its
+	// contribution wasn't merged.
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Enforcement is set if the violation didn't block the key (warn), or
+	// is a violation of the candidate schema (audit).
+	Enforcement SchemaEnforcement `json:"enforcement,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -127,6 +594,21 @@ func (m *MergeTarget) IsStatusNewlyCreated() bool {
 	return m.Status.NewlyCreated == DataNewlyCreatedStatusYes
 }
 
+// ResetStatus forgets everything about the managed target ConfigMap, and
+// starts managing the given one.
+//
+// The handled requests, and the frozen contributions of the suspended
+// MergeSources, are kept: they don't depend on the target ConfigMap, and
+// requests that were handled already mustn't be handled again.
+func (m *MergeTarget) ResetStatus(target string) {
+	m.Status = MergeTargetStatus{
+		Target:           target,
+		Conditions:       m.Status.Conditions,
+		HandledRequests:  m.Status.HandledRequests,
+		SuspendedSources: m.Status.SuspendedSources,
+	}
+}
+
 // SetStatusCondition sets the v1beta1.Condition.
 func (m *MergeTarget) SetStatusCondition(c metav1.Condition) {
 	meta.SetStatusCondition(&m.Status.Conditions, c)
@@ -136,6 +618,11 @@ func (m *MergeTarget) FindStatusCondition(conditionType string) *metav1.Conditio
 	return meta.FindStatusCondition(m.Status.Conditions, conditionType)
 }
 
+// RemoveStatusCondition removes the condition of the given type.
+func (m *MergeTarget) RemoveStatusCondition(conditionType string) {
+	meta.RemoveStatusCondition(&m.Status.Conditions, conditionType)
+}
+
 // UpdateDataStatus updates the data/status keys of the MergeTarget depending
 // the ConfigMap's data.
 //
@@ -147,7 +634,17 @@ func (m *MergeTarget) UpdateDataStatus(configMapData map[string]string) {
 		m.Status.Data = map[string]MergeTargetDataStatus{}
 	}
 
+	for k := range m.Status.HandedOver {
+		if _, ok := m.Spec.Data[k]; !ok {
+			delete(m.Status.HandedOver, k)
+		}
+	}
+
 	for k, v := range m.Spec.Data {
+		if _, ok := m.Status.HandedOver[k]; ok {
+			continue
+		}
+
 		var (
 			nextState                  MergeTargetDataStatus
 			existingData, dataExists   = configMapData[k]
@@ -174,15 +671,562 @@ func (m *MergeTarget) UpdateDataStatus(configMapData map[string]string) {
 	}
 }
 
-// ReduceDataState mutates configMapData, accumulating the MergeSourceList into the respective keys.
+// SchemaWarning reports the values of a key that don't validate, without
+// blocking it (with the warn or audit enforcement).
+//
+// +kubebuilder:object:generate=false
+type SchemaWarning struct {
+	Key         string
+	Enforcement SchemaEnforcement
+	Errors      []string
+
+	// Violations is the number of schema violations of the key, which can
+	// be more than the validation errors kept in the status.
+	Violations int
+}
+
+// Contribution is the data a single source ConfigMap contributes to a data key
+// of a MergeTarget.
+//
+// +kubebuilder:object:generate=false
+type Contribution struct {
+	// Key is the data key of the MergeTarget.
+	Key string
+
+	// MergeSource is the MergeSource that selected the ConfigMap.
+	MergeSource types.NamespacedName
+
+	// ConfigMap is the source ConfigMap.
+	ConfigMap types.NamespacedName
+
+	// Data is the value of the source key of the ConfigMap.
+	Data string
+
+	// ResourceVersion is the resourceVersion of the source ConfigMap.
+	ResourceVersion string
+
+	// UID is the UID of the source ConfigMap.
+	UID types.UID
+
+	// AnnotateSource is true if the MergeSource opted in to annotating its source ConfigMaps.
+	AnnotateSource bool
+
+	// DryRun is true if the source ConfigMap only asks for its contribution
+	// to be validated: it is never merged.
+	DryRun bool
+
+	// Rejected is why the contribution is invalid, set by ReduceDataState.
+	Rejected string
+
+	// Blocked is why the contribution, valid on its own, isn't written to the
+	// target, e.g. because the merged value of the key is invalid.
+	Blocked string
+}
+
+// BlockContributions marks the contributions to the keys that aren't rejected
+// (or blocked) already as blocked, for the reason given by key.
+func BlockContributions(contributions []Contribution, reasons map[string]string) {
+	for i, c := range contributions {
+		if reason, ok := reasons[c.Key]; ok && !c.DryRun && c.Rejected == "" && c.Blocked == "" {
+			contributions[i].Blocked = reason
+		}
+	}
+}
+
+// LastKnownGood holds the last valid contributions of source ConfigMaps, by digest.
+//
+// +kubebuilder:object:generate=false
+type LastKnownGood map[string]string
+
+// Add adds the contribution, and returns its digest.
+func (g LastKnownGood) Add(data string) string {
+	digest := util.Digest(data)
+	if g != nil {
+		g[digest] = data
+	}
+
+	return digest
+}
+
+// LastKnownGoodDigests returns the digests of the last valid contributions the
+// status of the MergeTarget refers to.
+func (m *MergeTarget) LastKnownGoodDigests() []string {
+	var digests []string
+	for _, v := range m.Status.Data {
+		for _, digest := range v.LastKnownGoodDigests {
+			if !util.ContainsString(digests, digest) {
+				digests = append(digests, digest)
+			}
+		}
+	}
+
+	sort.Strings(digests)
+	return digests
+}
+
+// FreezeContributions replaces the contributions of the suspended MergeSources
+// by the ones they had when they were suspended (marked as blocked), recording
+// them the first time a MergeSource is seen suspended, and forgetting them
+// once it's resumed.
+//
+// The contributions are sorted like listed: by MergeSource, then ConfigMap.
+func (m *MergeTarget) FreezeContributions(contributions []Contribution, suspended []types.NamespacedName) []Contribution {
+	frozen := map[string][]SuspendedContribution{}
+	for _, ms := range suspended {
+		name := ms.String()
+		if previous, ok := m.Status.SuspendedSources[name]; ok {
+			frozen[name] = previous
+			continue
+		}
+
+		frozen[name] = []SuspendedContribution{}
+		for _, c := range contributions {
+			if c.MergeSource == ms && !c.DryRun {
+				frozen[name] = append(frozen[name], SuspendedContribution{
+					Key: c.Key, ConfigMap: c.ConfigMap.String(), Data: c.Data,
+				})
+			}
+		}
+	}
+
+	if len(frozen) == 0 {
+		m.Status.SuspendedSources = nil
+		return contributions
+	}
+
+	m.Status.SuspendedSources = frozen
+
+	var (
+		result = make([]Contribution, 0, len(contributions))
+		live   = map[string]Contribution{}
+	)
+
+	for _, c := range contributions {
+		if _, ok := frozen[c.MergeSource.String()]; !ok {
+			result = append(result, c)
+		} else if !c.DryRun {
+			live[c.Key+"/"+c.ConfigMap.String()] = c
+		}
+	}
+
+	for _, ms := range suspended {
+		for _, f := range frozen[ms.String()] {
+			c, ok := live[f.Key+"/"+f.ConfigMap]
+			if !ok {
+				name, err := util.NamespacedName(f.ConfigMap, "")
+				if err != nil {
+					continue
+				}
+
+				c = Contribution{Key: f.Key, MergeSource: ms, ConfigMap: name}
+			}
+
+			c.Data, c.Blocked = f.Data, "its MergeSource is suspended"
+			result = append(result, c)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].MergeSource != result[j].MergeSource {
+			return result[i].MergeSource.String() < result[j].MergeSource.String()
+		}
+
+		return result[i].ConfigMap.String() < result[j].ConfigMap.String()
+	})
+
+	return result
+}
+
+// mergeContributions merges the contributions to the key, after its initial value,
+// and returns the contributions that were merged.
+//
+// If the key isolates sources, invalid contributions are left out (or replaced
+// by the last valid contribution of the same source, from lastKnownGood), and
+// reported as errors. The valid contributions are added to lastKnownGood.
+// Dry-run contributions are validated (see validateDryRun), but never merged.
+func (m *MergeTarget) mergeContributions(
+	docs Documents, k string, status *MergeTargetDataStatus, contributions []Contribution, lastKnownGood LastKnownGood,
+) (string, []Contribution, []string) {
+	var (
+		spec   = m.Spec.Data[k]
+		data   = status.Init
+		merged []Contribution
+		errs   []string
+		lastOK map[string]string
+	)
+
+	for i, c := range contributions {
+		if c.Key != k || c.DryRun {
+			continue
+		} else if !spec.IsolatesSources() {
+			data += c.Data
+			merged = append(merged, c)
+			continue
+		}
+
+		source := c.ConfigMap.String()
+		if err := spec.validateContribution(docs, c.Data); err != nil {
+			var invalid *validator.InvalidContentError
+			if errors.As(err, &invalid) {
+				invalid.Key, invalid.MergeSource, invalid.ConfigMap = k, c.MergeSource.String(), source
+				m.recordValidationErrors(invalid, spec.Enforcement == SchemaEnforcementWarn)
+			}
+
+			errs = append(errs, fmt.Sprintf("%s: source %s: %s", k, source, err.Error()))
+			if spec.Enforcement == SchemaEnforcementWarn {
+				data += c.Data
+				merged = append(merged, c)
+				continue
+			}
+
+			contributions[i].Rejected = err.Error()
+			digest := status.LastKnownGoodDigests[source]
+			if good, ok := lastKnownGood[digest]; ok && spec.InvalidSources == InvalidSourcePolicyLastKnownGood {
+				data += good
+				lastOK = setString(lastOK, source, digest)
+				c.Data = good
+				merged = append(merged, c)
+			}
+
+			continue
+		}
+
+		data += c.Data
+		merged = append(merged, c)
+		if spec.InvalidSources == InvalidSourcePolicyLastKnownGood {
+			lastOK = setString(lastOK, source, lastKnownGood.Add(c.Data))
+		}
+	}
+
+	status.LastKnownGoodDigests = lastOK
+
+	for i, c := range contributions {
+		if c.Key == k && c.DryRun {
+			m.validateDryRun(docs, k, status.Init, data, merged, &contributions[i])
+		}
+	}
+
+	return data, merged, errs
+}
+
+// MergedContributions returns the contributions to the key that are merged,
+// leaving out (or replacing) the invalid ones like ReduceDataState does,
+// without changing the MergeTarget, the contributions or lastKnownGood.
+func (m *MergeTarget) MergedContributions(
+	k string, contributions []Contribution, lastKnownGood LastKnownGood,
+) []Contribution {
+	var (
+		mt     = m.DeepCopy()
+		status = mt.Status.Data[k]
+		goods  = make(LastKnownGood, len(lastKnownGood))
+	)
+
+	for digest, data := range lastKnownGood {
+		goods[digest] = data
+	}
+
+	_, merged, _ := mt.mergeContributions(Documents{}, k, &status, append([]Contribution(nil), contributions...), goods)
+	return merged
+}
+
+// validateDryRun validates the dry-run contribution as if it was merged
+// (after all the others), marking it as rejected if it is invalid.
+func (m *MergeTarget) validateDryRun(docs Documents, k, init, data string, merged []Contribution, c *Contribution) {
+	var (
+		spec = m.Spec.Data[k]
+		errs []*validator.InvalidContentError
+		err  error
+	)
+
+	merged = append(merged[:len(merged):len(merged)], *c)
+	switch {
+	case spec.IsolatesSources():
+		err = spec.validateContribution(docs, c.Data)
+	case spec.JSONSchema != "" && data+c.Data != "":
+		var doc *validator.Document
+		if doc, err = docs.merged(init, merged, data+c.Data); err == nil {
+			err = spec.validate(spec.JSONSchema, doc)
+		}
+	}
+
+	if err == nil {
+		return
+	}
+
+	var invalid *validator.InvalidContentError
+	if !errors.As(err, &invalid) {
+		c.Rejected = err.Error()
+		return
+	}
+
+	if spec.IsolatesSources() {
+		invalid.Key, invalid.MergeSource, invalid.ConfigMap = k, c.MergeSource.String(), c.ConfigMap.String()
+		errs = append(errs, invalid)
+	} else {
+		// the merged value may be invalid already, only the violations in
+		// the contribution itself are its own.
+		for _, e := range attributeViolations(docs, k, init, merged, invalid) {
+			if e.ConfigMap == c.ConfigMap.String() && e.MergeSource == c.MergeSource.String() {
+				errs = append(errs, e)
+			}
+		}
+	}
+
+	start := len(m.Status.ValidationErrors)
+	for _, e := range errs {
+		m.recordValidationErrors(e, false)
+		c.reject(e.Violations)
+	}
+
+	for i := start; i < len(m.Status.ValidationErrors); i++ {
+		m.Status.ValidationErrors[i].DryRun = true
+	}
+}
+
+// recordValidationErrors records the violations of invalid in the status, as
+// non-blocking with the warn or audit enforcement. They are bounded by
+// truncateValidationErrors once all of them are recorded.
+func (m *MergeTarget) recordValidationErrors(invalid *validator.InvalidContentError, nonBlocking bool) {
+	var enforcement SchemaEnforcement
+	if nonBlocking {
+		enforcement = m.Spec.Data[invalid.Key].Enforcement
+	}
+
+	for _, v := range invalid.Violations {
+		m.Status.ValidationErrors = append(m.Status.ValidationErrors, MergeTargetValidationError{
+			Key:         invalid.Key,
+			MergeSource: invalid.MergeSource,
+			ConfigMap:   invalid.ConfigMap,
+			Pointer:     v.Pointer,
+			Rule:        v.Rule,
+			Message:     v.Message,
+			Enforcement: enforcement,
+		})
+	}
+}
+
+// truncateValidationErrors sorts the validation errors of the status, and
+// keeps the first MaxValidationErrors of them, so that the same ones are kept
+// whatever order they were found in.
+func (m *MergeTarget) truncateValidationErrors() {
+	errs := m.Status.ValidationErrors
+	sort.SliceStable(errs, func(i, j int) bool {
+		a, b := errs[i], errs[j]
+		switch {
+		case a.Key != b.Key:
+			return a.Key < b.Key
+		case a.DryRun != b.DryRun:
+			return !a.DryRun
+		case a.MergeSource != b.MergeSource:
+			return a.MergeSource < b.MergeSource
+		case a.ConfigMap != b.ConfigMap:
+			return a.ConfigMap < b.ConfigMap
+		case a.Pointer != b.Pointer:
+			return a.Pointer < b.Pointer
+		case a.Rule != b.Rule:
+			return a.Rule < b.Rule
+		default:
+			return a.Message < b.Message
+		}
+	})
+
+	if len(errs) > MaxValidationErrors {
+		m.Status.ValidationErrors = errs[:MaxValidationErrors]
+	}
+}
+
+// rejectContribution marks the contribution the violations are attributed to as rejected.
+func rejectContribution(contributions []Contribution, invalid *validator.InvalidContentError) {
+	for i, c := range contributions {
+		if c.Key == invalid.Key && !c.DryRun &&
+			c.ConfigMap.String() == invalid.ConfigMap && c.MergeSource.String() == invalid.MergeSource {
+			contributions[i].reject(invalid.Violations)
+		}
+	}
+}
+
+func (c *Contribution) reject(violations []validator.Violation) {
+	for _, v := range violations {
+		if c.Rejected != "" {
+			c.Rejected += "; "
+		}
+
+		c.Rejected += fmt.Sprintf("%s: %s", v.Pointer, v.Message)
+	}
+}
+
+// attributeViolations splits the violations of the merged value of the key
+// by the contribution they are in, when the value is a YAML list: the pointers
+// are then relative to the contribution. Violations of the initial value, or
+// of the value as a whole, are not attributed to any source.
+//
+// The entries of the initial value and of the contributions are counted once,
+// from their documents.
+func attributeViolations(
+	docs Documents, k, init string, merged []Contribution, invalid *validator.InvalidContentError,
+) []*validator.InvalidContentError {
+	var (
+		attributed = make([]*validator.InvalidContentError, 0, len(invalid.Violations))
+		initSize   = docs.entries(init)
+		sizes      = make([]int, len(merged))
+	)
+
+	for i, c := range merged {
+		sizes[i] = docs.entries(c.Data)
+	}
+
+	for _, v := range invalid.Violations {
+		e := &validator.InvalidContentError{Key: k, Violations: []validator.Violation{v}}
+		attributed = append(attributed, e)
+
+		parts := strings.SplitN(strings.TrimPrefix(v.Pointer, "/"), "/", 2)
+		n, err := strconv.Atoi(parts[0])
+		if v.Pointer == "" || err != nil {
+			continue
+		}
+
+		rest := ""
+		if len(parts) == 2 {
+			rest = "/" + parts[1]
+		}
+
+		n -= initSize
+		for i, c := range merged {
+			size := sizes[i]
+			if n >= 0 && n < size {
+				e.MergeSource, e.ConfigMap = c.MergeSource.String(), c.ConfigMap.String()
+				e.Violations[0].Pointer = fmt.Sprintf("/%d%s", n, rest)
+				break
+			}
+
+			n -= size
+		}
+	}
+
+	return attributed
+}
+
+// Documents parses every distinct value once, so that its document is shared
+// by the merge, the validations and the outcomes of the contributions of a
+// reconciliation. Documents are never dropped, so a reconciliation starts
+// with new ones.
+//
+// +kubebuilder:object:generate=false
+type Documents map[string]*parsedDocument
+
+type parsedDocument struct {
+	doc *validator.Document
+	err error
+}
+
+func (d Documents) parse(data string) (*validator.Document, error) {
+	p, ok := d[data]
+	if !ok {
+		p = &parsedDocument{}
+		p.doc, p.err = validator.Parse(data)
+		d[data] = p
+	}
+
+	return p.doc, errors.WithStack(p.err)
+}
+
+// entries returns the number of entries of the value (see entries.Parse), from
+// its document.
+func (d Documents) entries(data string) int {
+	if doc, err := d.parse(data); err == nil {
+		if n, ok := doc.Len(); ok {
+			return n
+		}
+	}
+
+	return len(entries.Lines(data))
+}
+
+// Items returns the entries of the value (as entries.Parse does), from its
+// document: the items of a list as compact JSON, or its lines otherwise.
+func (d Documents) Items(data string) []string {
+	doc, err := d.parse(data)
+	if err != nil {
+		return entries.Lines(data)
+	}
+
+	items, ok := doc.Items()
+	if !ok {
+		return entries.Lines(data)
+	}
+
+	parsed := make([]string, 0, len(items))
+	for _, item := range items {
+		parsed = append(parsed, item.String())
+	}
+
+	return parsed
+}
+
+// merged returns the document of the merged value of a key, from the
+// documents of its initial value and of the merged contributions if possible.
+func (d Documents) merged(init string, merged []Contribution, data string) (*validator.Document, error) {
+	parts := make([]*validator.Document, 0, len(merged)+1)
+	for _, v := range append([]string{init}, contributionData(merged)...) {
+		doc, err := d.parse(v)
+		if err != nil {
+			// it may still be valid once merged.
+			return d.parse(data)
+		}
+
+		parts = append(parts, doc)
+	}
+
+	if doc, ok := validator.Concat(parts...); ok {
+		return doc, nil
+	}
+
+	return d.parse(data)
+}
+
+func contributionData(contributions []Contribution) []string {
+	data := make([]string, 0, len(contributions))
+	for _, c := range contributions {
+		data = append(data, c.Data)
+	}
+
+	return data
+}
+
+func setString(m map[string]string, k, v string) map[string]string {
+	if m == nil {
+		m = map[string]string{}
+	}
+
+	m[k] = v
+	return m
+}
+
+// ReduceDataState mutates configMapData, accumulating the contributions into the respective keys.
+//
+// Contributions are merged in the order they are given, and the invalid ones are marked as rejected.
+// Keys are reduced in sorted order, so that errors and warnings are always reported in the same order.
+// The last valid contributions are looked up in (and added to) lastKnownGood, and values are parsed
+// with docs.
 //
 //nolint:cyclop
 func (m *MergeTarget) ReduceDataState(
-	mergeSources MergeSourceList, configMapData *map[string]string,
-) (statusKeysToRemove []string, updatedKeys int, fieldsErrors []string) {
+	docs Documents, contributions []Contribution, lastKnownGood LastKnownGood, configMapData *map[string]string,
+) (statusKeysToRemove []string, updatedKeys int, fieldsErrors []string, schemaWarnings []SchemaWarning) {
 	configMap := *configMapData
 
-	for k, v := range m.Status.Data {
+	m.Status.ValidationErrors = nil
+	defer m.truncateValidationErrors()
+
+	keys := make([]string, 0, len(m.Status.Data))
+	for k := range m.Status.Data {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := m.Status.Data[k]
+
 		//
 		// If the Spec for the MergeTarget no longer has the key
 		// specified, we revert the configMap to its original state,
@@ -205,23 +1249,52 @@ func (m *MergeTarget) ReduceDataState(
 		}
 
 		//
-		// create & aggregate the data from the mergeSources
-		data := v.Init
-		for _, source := range mergeSources.Items {
-			if source.Spec.Target.Data == k {
-				data += source.Status.Output
-			}
+		// create & aggregate the data from the contributions
+		spec := m.Spec.Data[k]
+		recorded := len(m.Status.ValidationErrors)
+		data, merged, sourceErrors := m.mergeContributions(docs, k, &v, contributions, lastKnownGood)
+		m.Status.Data[k] = v
+
+		warning := SchemaWarning{Key: k, Enforcement: spec.Enforcement}
+		if spec.Enforcement == SchemaEnforcementWarn {
+			warning.Errors = append(warning.Errors, sourceErrors...)
+		} else {
+			fieldsErrors = append(fieldsErrors, sourceErrors...)
 		}
 
 		// possibly validate the field if JSONSchema was specified
 		// N.B. we _allow empty here_!
-		if m.Spec.Data[k].JSONSchema != "" && data != "" {
-			if err := validator.Validate(m.Spec.Data[k].JSONSchema, data); err != nil {
-				fieldsErrors = append(fieldsErrors, fmt.Sprintf("%s: %s", k, err.Error()))
-				continue
+		if spec.JSONSchema != "" && data != "" {
+			if err := m.validateMerged(
+				docs, k, spec.JSONSchema, v.Init, data, merged, contributions, spec.Enforcement == SchemaEnforcementWarn,
+			); err != nil {
+				if spec.Enforcement != SchemaEnforcementWarn {
+					fieldsErrors = append(fieldsErrors, fmt.Sprintf("%s: %s", k, err.Error()))
+					BlockContributions(contributions, map[string]string{k: "the merged value of the key is invalid"})
+					continue
+				}
+
+				warning.Errors = append(warning.Errors, fmt.Sprintf("%s: %s", k, err.Error()))
+			}
+		}
+
+		// the candidate schema is audited, and never blocks the key.
+		if spec.Enforcement == SchemaEnforcementAudit && spec.CandidateSchema != "" && data != "" {
+			if err := m.validateMerged(docs, k, spec.CandidateSchema, v.Init, data, merged, contributions, true); err != nil {
+				warning.Errors = append(warning.Errors, fmt.Sprintf("%s: candidate schema: %s", k, err.Error()))
 			}
 		}
 
+		if spec.Enforcement == SchemaEnforcementWarn || spec.Enforcement == SchemaEnforcementAudit {
+			for _, e := range m.Status.ValidationErrors[recorded:] {
+				if e.Enforcement != "" {
+					warning.Violations++
+				}
+			}
+
+			schemaWarnings = append(schemaWarnings, warning)
+		}
+
 		if configMap == nil {
 			configMap = map[string]string{}
 		}
@@ -235,7 +1308,180 @@ func (m *MergeTarget) ReduceDataState(
 
 	*configMapData = configMap
 
-	return statusKeysToRemove, updatedKeys, fieldsErrors
+	return statusKeysToRemove, updatedKeys, fieldsErrors, schemaWarnings
+}
+
+// ValidateData validates the data of the target ConfigMap against the schema
+// of the MergeTarget, checks its invariants, and records their violations by key.
+// The values are parsed with docs, and their documents make up the document of
+// the whole data.
+func (m *MergeTarget) ValidateData(docs Documents, data map[string]string) error {
+	if m.Spec.Schema == "" && len(m.Spec.Invariants) == 0 {
+		return nil
+	}
+
+	properties := make(map[string]*validator.Document, len(data))
+	for k, v := range data {
+		properties[k] = validator.String(v)
+		if v == "" || m.Spec.Data[k].Format == DataFormatText {
+			continue
+		} else if doc, err := docs.parse(v); err == nil {
+			properties[k] = doc
+		}
+	}
+
+	doc := validator.Object(properties)
+
+	var violations []validator.Violation
+	if m.Spec.Schema != "" {
+		v, err := validator.For(validator.Engine(m.Spec.SchemaEngine))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		var invalid *validator.InvalidContentError
+		if err := v.Validate(m.Spec.Schema, doc); errors.As(err, &invalid) {
+			violations = append(violations, invalid.Violations...)
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if len(m.Spec.Invariants) > 0 {
+		parsed, err := doc.Value()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		violations = append(violations, m.checkInvariants(parsed.(map[string]interface{}))...)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	for _, v := range violations {
+		parts := strings.SplitN(strings.TrimPrefix(v.Pointer, "/"), "/", 2)
+		e := &validator.InvalidContentError{Violations: []validator.Violation{v}}
+		if v.Pointer != "" {
+			e.Key, e.Violations[0].Pointer = unescapePointer(parts[0]), ""
+			if len(parts) == 2 {
+				e.Violations[0].Pointer = "/" + parts[1]
+			}
+		}
+
+		m.recordValidationErrors(e, false)
+	}
+
+	m.truncateValidationErrors()
+	return errors.WithStack(&validator.InvalidContentError{Violations: violations})
+}
+
+// validateMerged validates the merged value of the key against the schema,
+// and records its violations. Unless they are non-blocking, the contributions
+// they are attributed to are rejected.
+func (m *MergeTarget) validateMerged(
+	docs Documents, k, schema, init, data string, merged, contributions []Contribution, nonBlocking bool,
+) error {
+	doc, err := docs.merged(init, merged, data)
+	if err == nil {
+		spec := m.Spec.Data[k]
+		err = spec.validate(schema, doc)
+	}
+
+	var invalid *validator.InvalidContentError
+	if errors.As(err, &invalid) {
+		for _, e := range attributeViolations(docs, k, init, merged, invalid) {
+			m.recordValidationErrors(e, nonBlocking)
+			if !nonBlocking {
+				rejectContribution(contributions, e)
+			}
+		}
+	}
+
+	return errors.WithStack(err)
+}
+
+// InitFromName is the name of the ConfigMap the key's initial value is loaded from.
+func (m *MergeTarget) InitFromName(key string) (types.NamespacedName, bool, error) {
+	initFrom := m.Spec.Data[key].InitFrom
+	if initFrom == nil {
+		return types.NamespacedName{}, false, nil
+	}
+
+	n, err := util.NamespacedName(initFrom.ConfigMap, m.Namespace)
+	return n, true, errors.WithStack(err)
+}
+
+// SetInits sets the initial value of the managed keys.
+func (m *MergeTarget) SetInits(inits map[string]string) {
+	for k, v := range inits {
+		if s, ok := m.Status.Data[k]; ok {
+			s.Init = v
+			m.Status.Data[k] = s
+		}
+	}
+}
+
+// RecordApplied records the digests of the values of the managed keys, as
+// they were written, except for the given (drifted) keys.
+func (m *MergeTarget) RecordApplied(data map[string]string, except []string) {
+	for k, s := range m.Status.Data {
+		if _, ok := m.Spec.Data[k]; !ok || util.ContainsString(except, k) {
+			continue
+		}
+
+		s.AppliedDigest = util.Digest(data[k])
+		s.DriftedDigest = ""
+		m.Status.Data[k] = s
+	}
+}
+
+// HandOver stops managing the keys, recording that they were handed over to
+// the MergeTarget named to, and returns their status.
+func (m *MergeTarget) HandOver(keys []string, to string) map[string]MergeTargetDataStatus {
+	data := map[string]MergeTargetDataStatus{}
+	for _, k := range keys {
+		v, ok := m.Status.Data[k]
+		if !ok {
+			continue
+		}
+
+		if m.Status.HandedOver == nil {
+			m.Status.HandedOver = map[string]string{}
+		}
+
+		data[k] = v
+		m.Status.HandedOver[k] = to
+		delete(m.Status.Data, k)
+	}
+
+	return data
+}
+
+// AcceptHandover starts managing the keys handed over by another MergeTarget,
+// with the status they had. It returns false if there was nothing left to accept.
+func (m *MergeTarget) AcceptHandover(data map[string]MergeTargetDataStatus, newlyCreated string) bool {
+	accepted := false
+	for k, v := range data {
+		if _, ok := m.Status.Data[k]; ok {
+			continue
+		}
+
+		if m.Status.Data == nil {
+			m.Status.Data = map[string]MergeTargetDataStatus{}
+		}
+
+		m.Status.Data[k] = v
+		accepted = true
+	}
+
+	if newlyCreated == DataNewlyCreatedStatusYes && !m.IsStatusNewlyCreated() {
+		m.Status.NewlyCreated = newlyCreated
+		accepted = true
+	}
+
+	return accepted
 }
 
 func (m *MergeTarget) RemoveDataStatusKeys(keys []string) {
@@ -0,0 +1,751 @@
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/cashapp/cmmc/util/entries"
+	"github.com/cashapp/cmmc/util/validator"
+)
+
+const roleSchema = `{"type":"object","required":["rolearn"]}`
+
+var source = types.NamespacedName{Namespace: "default", Name: "source"}
+
+func contribution(name, data string) Contribution {
+	return Contribution{
+		Key:         "mapRoles",
+		MergeSource: source,
+		ConfigMap:   types.NamespacedName{Namespace: "default", Name: name},
+		Data:        data,
+	}
+}
+
+func dryRunContribution(name, data string) Contribution {
+	c := contribution(name, data)
+	c.DryRun = true
+	return c
+}
+
+func configMapNames(contributions []Contribution) []string {
+	names := []string{}
+	for _, c := range contributions {
+		names = append(names, c.ConfigMap.Name)
+	}
+
+	return names
+}
+
+func rejectedNames(contributions []Contribution) []string {
+	names := []string{}
+	for _, c := range contributions {
+		if c.Rejected != "" {
+			names = append(names, c.ConfigMap.Name)
+		}
+	}
+
+	return names
+}
+
+// lastKnownGood splits the last valid contributions by source into their
+// digests by source, and their contents.
+func lastKnownGood(contributions map[string]string) (map[string]string, LastKnownGood) {
+	goods := LastKnownGood{}
+	if contributions == nil {
+		return nil, goods
+	}
+
+	digests := map[string]string{}
+	for source, data := range contributions {
+		digests[source] = goods.Add(data)
+	}
+
+	return digests, goods
+}
+
+// lastKnownGoodBySource returns the last valid contributions the digests refer to, by source.
+func lastKnownGoodBySource(digests map[string]string, goods LastKnownGood) map[string]string {
+	if digests == nil {
+		return nil
+	}
+
+	contributions := map[string]string{}
+	for source, digest := range digests {
+		contributions[source] = goods[digest]
+	}
+
+	return contributions
+}
+
+func TestMergeContributions(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		spec          MergeTargetDataSpec
+		lastKnownGood map[string]string
+		contributions []Contribution
+
+		data              string
+		merged            []string
+		mergedData        []string
+		rejected          []string
+		wantLastKnownGood map[string]string
+	}{
+		{
+			name: "concatenates the contributions after the initial value",
+			contributions: []Contribution{
+				contribution("a", "- rolearn: a\n"),
+				contribution("b", "- username: b\n"),
+				dryRunContribution("dry", "- rolearn: dry\n"),
+			},
+			data:       "- rolearn: init\n- rolearn: a\n- username: b\n",
+			merged:     []string{"a", "b"},
+			mergedData: []string{"- rolearn: a\n", "- username: b\n"},
+			rejected:   []string{},
+		},
+		{
+			name: "leaves out the invalid contributions",
+			spec: MergeTargetDataSpec{ItemSchema: roleSchema, InvalidSources: InvalidSourcePolicyExclude},
+			contributions: []Contribution{
+				contribution("a", "- rolearn: a\n"),
+				contribution("b", "- username: b\n"),
+			},
+			data:       "- rolearn: init\n- rolearn: a\n",
+			merged:     []string{"a"},
+			mergedData: []string{"- rolearn: a\n"},
+			rejected:   []string{"b"},
+		},
+		{
+			name:          "replaces the invalid contributions by the last valid ones",
+			spec:          MergeTargetDataSpec{ItemSchema: roleSchema, InvalidSources: InvalidSourcePolicyLastKnownGood},
+			lastKnownGood: map[string]string{"default/b": "- rolearn: b\n", "default/gone": "- rolearn: gone\n"},
+			contributions: []Contribution{
+				contribution("a", "- rolearn: a\n"),
+				contribution("b", "- username: b\n"),
+			},
+			data:       "- rolearn: init\n- rolearn: a\n- rolearn: b\n",
+			merged:     []string{"a", "b"},
+			mergedData: []string{"- rolearn: a\n", "- rolearn: b\n"},
+			rejected:   []string{"b"},
+			wantLastKnownGood: map[string]string{
+				"default/a": "- rolearn: a\n",
+				"default/b": "- rolearn: b\n",
+			},
+		},
+		{
+			name: "leaves out invalid contributions that were never valid",
+			spec: MergeTargetDataSpec{ItemSchema: roleSchema, InvalidSources: InvalidSourcePolicyLastKnownGood},
+			contributions: []Contribution{
+				contribution("a", "- rolearn: a\n"),
+				contribution("b", "- username: b\n"),
+			},
+			data:              "- rolearn: init\n- rolearn: a\n",
+			merged:            []string{"a"},
+			mergedData:        []string{"- rolearn: a\n"},
+			rejected:          []string{"b"},
+			wantLastKnownGood: map[string]string{"default/a": "- rolearn: a\n"},
+		},
+		{
+			name: "merges invalid contributions when the schema isn't enforced",
+			spec: MergeTargetDataSpec{
+				ItemSchema: roleSchema, InvalidSources: InvalidSourcePolicyExclude, Enforcement: SchemaEnforcementWarn,
+			},
+			contributions: []Contribution{
+				contribution("a", "- rolearn: a\n"),
+				contribution("b", "- username: b\n"),
+			},
+			data:       "- rolearn: init\n- rolearn: a\n- username: b\n",
+			merged:     []string{"a", "b"},
+			mergedData: []string{"- rolearn: a\n", "- username: b\n"},
+			rejected:   []string{},
+		},
+		{
+			name: "validates dry-run contributions without merging them",
+			spec: MergeTargetDataSpec{ItemSchema: roleSchema, InvalidSources: InvalidSourcePolicyExclude},
+			contributions: []Contribution{
+				contribution("a", "- rolearn: a\n"),
+				dryRunContribution("dry", "- username: dry\n"),
+			},
+			data:       "- rolearn: init\n- rolearn: a\n",
+			merged:     []string{"a"},
+			mergedData: []string{"- rolearn: a\n"},
+			rejected:   []string{"dry"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mt := &MergeTarget{Spec: MergeTargetSpec{Data: map[string]MergeTargetDataSpec{"mapRoles": tc.spec}}}
+			digests, goods := lastKnownGood(tc.lastKnownGood)
+			status := &MergeTargetDataStatus{Init: "- rolearn: init\n", LastKnownGoodDigests: digests}
+
+			data, merged, _ := mt.mergeContributions(Documents{}, "mapRoles", status, tc.contributions, goods)
+			assert.Equal(t, tc.data, data)
+			assert.Equal(t, tc.merged, configMapNames(merged))
+			assert.Equal(t, tc.mergedData, contributionData(merged))
+			assert.Equal(t, tc.rejected, rejectedNames(tc.contributions))
+			assert.Equal(t, tc.wantLastKnownGood, lastKnownGoodBySource(status.LastKnownGoodDigests, goods))
+		})
+	}
+}
+
+func TestMergedContributions(t *testing.T) {
+	digests, goods := lastKnownGood(map[string]string{"default/b": "- rolearn: b\n"})
+	mt := &MergeTarget{
+		Spec: MergeTargetSpec{Data: map[string]MergeTargetDataSpec{
+			"mapRoles": {ItemSchema: roleSchema, InvalidSources: InvalidSourcePolicyLastKnownGood},
+		}},
+		Status: MergeTargetStatus{Data: map[string]MergeTargetDataStatus{
+			"mapRoles": {LastKnownGoodDigests: digests},
+		}},
+	}
+	contributions := []Contribution{
+		contribution("a", "- rolearn: a\n"),
+		contribution("b", "- username: b\n"),
+		contribution("c", "- username: c\n"),
+	}
+
+	merged := mt.MergedContributions("mapRoles", contributions, goods)
+	assert.Equal(t, []string{"a", "b"}, configMapNames(merged))
+	assert.Equal(t, []string{"- rolearn: a\n", "- rolearn: b\n"}, contributionData(merged))
+
+	// neither the MergeTarget nor the contributions change.
+	assert.Empty(t, rejectedNames(contributions))
+	assert.Empty(t, mt.Status.ValidationErrors)
+	assert.Equal(t, digests, mt.Status.Data["mapRoles"].LastKnownGoodDigests)
+	assert.Len(t, goods, 1)
+}
+
+func TestLastKnownGoodDigests(t *testing.T) {
+	mt := &MergeTarget{Status: MergeTargetStatus{Data: map[string]MergeTargetDataStatus{
+		"mapRoles": {LastKnownGoodDigests: map[string]string{"default/a": "b", "default/b": "a"}},
+		"mapUsers": {LastKnownGoodDigests: map[string]string{"default/a": "a"}},
+		"other":    {},
+	}}}
+
+	assert.Equal(t, []string{"a", "b"}, mt.LastKnownGoodDigests())
+}
+
+func TestAttributeViolations(t *testing.T) {
+	merged := []Contribution{
+		contribution("a", "- rolearn: a\n"),
+		contribution("b", "- rolearn: b1\n- rolearn: b2\n"),
+	}
+
+	for _, tc := range []struct {
+		pointer string
+
+		configMap string
+		want      string
+	}{
+		{pointer: "/0/rolearn", want: "/0/rolearn"},
+		{pointer: "/1/rolearn", configMap: "default/a", want: "/0/rolearn"},
+		{pointer: "/2", configMap: "default/b", want: "/0"},
+		{pointer: "/3/groups/0", configMap: "default/b", want: "/1/groups/0"},
+		{pointer: "/4", want: "/4"},
+		{pointer: "", want: ""},
+		{pointer: "/rolearn", want: "/rolearn"},
+	} {
+		t.Run(tc.pointer, func(t *testing.T) {
+			invalid := &validator.InvalidContentError{Violations: []validator.Violation{
+				{Pointer: tc.pointer, Rule: "required", Message: "rolearn is required"},
+			}}
+
+			attributed := attributeViolations(Documents{}, "mapRoles", "- rolearn: init\n", merged, invalid)
+			require.Len(t, attributed, 1)
+			assert.Equal(t, "mapRoles", attributed[0].Key)
+			assert.Equal(t, tc.configMap, attributed[0].ConfigMap)
+			assert.Equal(t, tc.want, attributed[0].Violations[0].Pointer)
+			if tc.configMap != "" {
+				assert.Equal(t, source.String(), attributed[0].MergeSource)
+			}
+		})
+	}
+}
+
+func TestDocumentsEntries(t *testing.T) {
+	docs := Documents{}
+	for _, data := range []string{
+		"", "# nothing\n", "- rolearn: a\n- rolearn: b\n", "[]", "a\n\nb\n", "rolearn: a\n", "- a\n  b: [\n",
+		"- username: u\n  rolearn: a\n  groups: [g]\n  n: 1.5\n",
+	} {
+		assert.Equal(t, len(entries.Parse(data)), docs.entries(data), data)
+		assert.Equal(t, entries.Parse(data), docs.Items(data), data)
+	}
+
+	// every value is parsed once.
+	assert.Len(t, docs, 8)
+	docs.entries("- rolearn: a\n- rolearn: b\n")
+	assert.Len(t, docs, 8)
+}
+
+func TestValidateDryRun(t *testing.T) {
+	const listSchema = `{"type":"array","items":{"required":["rolearn"]}}`
+
+	for _, tc := range []struct {
+		name   string
+		spec   MergeTargetDataSpec
+		merged []Contribution
+		dryRun string
+
+		pointers []string
+	}{
+		{
+			name:   "accepts a valid contribution",
+			spec:   MergeTargetDataSpec{ItemSchema: roleSchema, InvalidSources: InvalidSourcePolicyExclude},
+			dryRun: "- rolearn: dry\n",
+		},
+		{
+			name:     "validates the contribution on its own when the key isolates sources",
+			spec:     MergeTargetDataSpec{ItemSchema: roleSchema, InvalidSources: InvalidSourcePolicyExclude},
+			dryRun:   "- rolearn: dry\n- username: dry\n",
+			pointers: []string{"/1"},
+		},
+		{
+			name:     "validates the contribution merged after the others",
+			spec:     MergeTargetDataSpec{JSONSchema: listSchema},
+			merged:   []Contribution{contribution("a", "- rolearn: a\n")},
+			dryRun:   "- username: dry\n",
+			pointers: []string{"/0"},
+		},
+		{
+			name:   "ignores the violations of the other contributions",
+			spec:   MergeTargetDataSpec{JSONSchema: listSchema},
+			merged: []Contribution{contribution("a", "- username: a\n")},
+			dryRun: "- rolearn: dry\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mt   = &MergeTarget{Spec: MergeTargetSpec{Data: map[string]MergeTargetDataSpec{"mapRoles": tc.spec}}}
+				c    = dryRunContribution("dry", tc.dryRun)
+				init = "- rolearn: init\n"
+			)
+
+			mt.validateDryRun(Documents{}, "mapRoles", init, init+strings.Join(contributionData(tc.merged), ""), tc.merged, &c)
+
+			pointers := []string{}
+			for _, e := range mt.Status.ValidationErrors {
+				assert.True(t, e.DryRun)
+				assert.Equal(t, "default/dry", e.ConfigMap)
+				pointers = append(pointers, e.Pointer)
+			}
+
+			if len(tc.pointers) == 0 {
+				assert.Empty(t, c.Rejected)
+				assert.Empty(t, pointers)
+			} else {
+				assert.NotEmpty(t, c.Rejected)
+				assert.Equal(t, tc.pointers, pointers)
+			}
+		})
+	}
+}
+
+func TestDocumentsMerged(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		init   string
+		merged []string
+
+		items int
+		err   bool
+	}{
+		{name: "concatenates block sequences", init: "- a\n", merged: []string{"- b\n", "- c\n"}, items: 3},
+		{name: "skips empty values", init: "", merged: []string{"- a\n", "", "# none\n"}, items: 1},
+		{name: "parses the merged value if a part doesn't parse", merged: []string{"- {a: 1,\n", "  b: 2}\n"}, items: 1},
+		{name: "parses the merged value if a part is open", merged: []string{"- a", "- b\n"}, items: 1},
+		{name: "fails if the merged value doesn't parse", merged: []string{"- a\n", "b: [\n"}, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var merged []Contribution
+			for _, data := range tc.merged {
+				merged = append(merged, contribution("a", data))
+			}
+
+			doc, err := Documents{}.merged(tc.init, merged, tc.init+strings.Join(tc.merged, ""))
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			items, ok := doc.Items()
+			assert.True(t, ok)
+			assert.Len(t, items, tc.items)
+		})
+	}
+}
+
+func TestValidateData(t *testing.T) {
+	const schema = `{"type":"object","properties":{` +
+		`"list":{"type":"array","maxItems":2,"items":{"required":["rolearn"]}},"text":{"type":"string"}}}`
+
+	mt := &MergeTarget{Spec: MergeTargetSpec{
+		Schema: schema,
+		Data: map[string]MergeTargetDataSpec{
+			"list": {},
+			"text": {Format: DataFormatText},
+		},
+	}}
+
+	for _, tc := range []struct {
+		name string
+		data map[string]string
+
+		errors []MergeTargetValidationError
+	}{
+		{
+			name: "accepts valid data",
+			data: map[string]string{"list": "- rolearn: a\n", "text": "- a\n", "other": "- b\n"},
+		},
+		{
+			name: "attributes the violations to their key",
+			data: map[string]string{"list": "- rolearn: a\n- username: b\n- rolearn: c\n"},
+			errors: []MergeTargetValidationError{
+				{Key: "list", Pointer: "", Rule: "array_max_items"},
+				{Key: "list", Pointer: "/1", Rule: "required"},
+			},
+		},
+		{
+			name:   "reports violations of the data as a whole",
+			data:   map[string]string{"list": "a: b\n"},
+			errors: []MergeTargetValidationError{{Key: "list", Pointer: "", Rule: "invalid_type"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mt := mt.DeepCopy()
+			err := mt.ValidateData(Documents{}, tc.data)
+			if len(tc.errors) == 0 {
+				assert.NoError(t, err)
+				assert.Empty(t, mt.Status.ValidationErrors)
+				return
+			}
+
+			assert.Error(t, err)
+			got := make([]MergeTargetValidationError, 0, len(mt.Status.ValidationErrors))
+			for _, e := range mt.Status.ValidationErrors {
+				got = append(got, MergeTargetValidationError{Key: e.Key, Pointer: e.Pointer, Rule: e.Rule})
+			}
+
+			assert.ElementsMatch(t, tc.errors, got)
+		})
+	}
+
+	assert.NoError(t, (&MergeTarget{}).ValidateData(Documents{}, map[string]string{"list": "a: b\n"}))
+}
+
+func TestValidateDataInvariants(t *testing.T) {
+	mt := &MergeTarget{Spec: MergeTargetSpec{
+		Data: map[string]MergeTargetDataSpec{
+			"mapRoles": {}, "mapUsers": {}, "config.yaml": {}, "upstreams.yaml": {},
+		},
+		Invariants: []MergeTargetInvariant{
+			{
+				Name: "usernames",
+				Unique: []MergeTargetDataPath{
+					{Key: "mapRoles", Path: "/*/username"},
+					{Key: "mapUsers", Path: "/*/username"},
+				},
+			},
+			{
+				Name: "upstreams",
+				References: &MergeTargetReferences{
+					Values:  []MergeTargetDataPath{{Key: "config.yaml", Path: "/routes/*/upstream"}},
+					Defined: []MergeTargetDataPath{{Key: "upstreams.yaml", Path: "/*/name"}},
+				},
+			},
+		},
+	}}
+
+	for _, tc := range []struct {
+		name string
+		data map[string]string
+
+		errors []MergeTargetValidationError
+	}{
+		{
+			name: "accepts valid data",
+			data: map[string]string{
+				"mapRoles":       "- username: a\n",
+				"mapUsers":       "- username: b\n",
+				"config.yaml":    "routes:\n  a:\n    upstream: u\n",
+				"upstreams.yaml": "- name: u\n",
+			},
+		},
+		{
+			name: "rejects a username in both mapRoles and mapUsers",
+			data: map[string]string{"mapRoles": "- username: a\n", "mapUsers": "- username: b\n- username: a\n"},
+			errors: []MergeTargetValidationError{
+				{Key: "mapUsers", Pointer: "/1/username", Rule: "unique"},
+			},
+		},
+		{
+			name: "rejects references to undefined values",
+			data: map[string]string{
+				"config.yaml":    "routes:\n  a:\n    upstream: u\n  b:\n    upstream: v\n",
+				"upstreams.yaml": "- name: u\n",
+			},
+			errors: []MergeTargetValidationError{
+				{Key: "config.yaml", Pointer: "/routes/b/upstream", Rule: "references"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mt := mt.DeepCopy()
+			err := mt.ValidateData(Documents{}, tc.data)
+			if len(tc.errors) == 0 {
+				assert.NoError(t, err)
+				assert.Empty(t, mt.Status.ValidationErrors)
+				return
+			}
+
+			assert.Error(t, err)
+			got := make([]MergeTargetValidationError, 0, len(mt.Status.ValidationErrors))
+			for _, e := range mt.Status.ValidationErrors {
+				got = append(got, MergeTargetValidationError{Key: e.Key, Pointer: e.Pointer, Rule: e.Rule})
+			}
+
+			assert.ElementsMatch(t, tc.errors, got)
+		})
+	}
+}
+
+func TestValidateDataSchemaEngine(t *testing.T) {
+	const schema = `{"type":"object","properties":{"list":{"type":"array"}},"unevaluatedProperties":false}`
+
+	data := map[string]string{"list": "- a\n", "other": "b"}
+
+	// draft-07 ignores unevaluatedProperties.
+	mt := &MergeTarget{Spec: MergeTargetSpec{Schema: schema, SchemaEngine: SchemaEngineDraft07}}
+	assert.NoError(t, mt.ValidateData(Documents{}, data))
+
+	mt = &MergeTarget{Spec: MergeTargetSpec{Schema: schema, SchemaEngine: SchemaEngine202012}}
+	assert.Error(t, mt.ValidateData(Documents{}, data))
+	assert.NotEmpty(t, mt.Status.ValidationErrors)
+}
+
+func TestFreezeContributions(t *testing.T) {
+	var (
+		suspended = types.NamespacedName{Namespace: "default", Name: "suspended"}
+		frozen    = func(name, data string) Contribution {
+			c := contribution(name, data)
+			c.MergeSource = suspended
+			return c
+		}
+		mt = &MergeTarget{}
+	)
+
+	contributions := []Contribution{contribution("a", "- a\n"), frozen("b", "- b\n")}
+	assert.Equal(t, contributions, mt.FreezeContributions(contributions, nil))
+	assert.Nil(t, mt.Status.SuspendedSources)
+
+	// the contributions are recorded when the MergeSource is suspended.
+	got := mt.FreezeContributions(contributions, []types.NamespacedName{suspended})
+	assert.Equal(t, []string{"a", "b"}, configMapNames(got))
+	assert.Equal(t, []string{"- a\n", "- b\n"}, contributionData(got))
+	assert.Equal(t, []string{"", "its MergeSource is suspended"}, []string{got[0].Blocked, got[1].Blocked})
+	assert.Equal(t, map[string][]SuspendedContribution{
+		suspended.String(): {{Key: "mapRoles", ConfigMap: "default/b", Data: "- b\n"}},
+	}, mt.Status.SuspendedSources)
+
+	// then merged instead of the current ones, even once their ConfigMap is gone.
+	got = mt.FreezeContributions([]Contribution{
+		contribution("a", "- a changed\n"), frozen("b", "- b changed\n"), frozen("c", "- c\n"),
+	}, []types.NamespacedName{suspended})
+	assert.Equal(t, []string{"a", "b"}, configMapNames(got))
+	assert.Equal(t, []string{"- a changed\n", "- b\n"}, contributionData(got))
+
+	got = mt.FreezeContributions([]Contribution{contribution("a", "- a\n")}, []types.NamespacedName{suspended})
+	assert.Equal(t, []string{"a", "b"}, configMapNames(got))
+	assert.Equal(t, suspended, got[1].MergeSource)
+	assert.Equal(t, "- b\n", got[1].Data)
+
+	// and forgotten once it's resumed.
+	got = mt.FreezeContributions([]Contribution{contribution("a", "- a\n")}, nil)
+	assert.Equal(t, []string{"a"}, configMapNames(got))
+	assert.Nil(t, mt.Status.SuspendedSources)
+}
+
+func TestResetStatus(t *testing.T) {
+	var (
+		suspended = types.NamespacedName{Namespace: "default", Name: "suspended"}
+		mt        = &MergeTarget{}
+	)
+
+	c := contribution("a", "- a\n")
+	c.MergeSource = suspended
+	mt.FreezeContributions([]Contribution{c}, []types.NamespacedName{suspended})
+	mt.Status.Data = map[string]MergeTargetDataStatus{"mapRoles": {Init: "- init\n"}}
+	mt.Status.HandledRequests = map[string]string{"config.cmmc.k8s.cash.app/acknowledge-removal": "1"}
+
+	mt.ResetStatus("default/target")
+	assert.Equal(t, "default/target", mt.Status.Target)
+	assert.Nil(t, mt.Status.Data)
+	assert.Equal(t, map[string]string{"config.cmmc.k8s.cash.app/acknowledge-removal": "1"}, mt.Status.HandledRequests)
+
+	// the frozen contributions are merged after the reset, not the current ones.
+	c.Data = "- a changed\n"
+	got := mt.FreezeContributions([]Contribution{c}, []types.NamespacedName{suspended})
+	assert.Equal(t, []string{"- a\n"}, contributionData(got))
+}
+
+func TestReduceDataStateValidationErrors(t *testing.T) {
+	spec := MergeTargetDataSpec{ItemSchema: roleSchema, InvalidSources: InvalidSourcePolicyExclude}
+	reduce := func(keys []string) []MergeTargetValidationError {
+		mt := &MergeTarget{Spec: MergeTargetSpec{Data: map[string]MergeTargetDataSpec{}}}
+		mt.Status.Data = map[string]MergeTargetDataStatus{}
+
+		var contributions []Contribution
+		for _, k := range keys {
+			mt.Spec.Data[k] = spec
+			mt.Status.Data[k] = MergeTargetDataStatus{}
+			for i := 0; i < MaxValidationErrors; i++ {
+				c := contribution(fmt.Sprintf("%s-%02d", k, i), "- username: invalid\n")
+				c.Key = k
+				contributions = append(contributions, c)
+			}
+		}
+
+		data := map[string]string{}
+		_, _, fieldsErrors, _ := mt.ReduceDataState(Documents{}, contributions, LastKnownGood{}, &data)
+		assert.Len(t, fieldsErrors, MaxValidationErrors*len(keys))
+		return mt.Status.ValidationErrors
+	}
+
+	errs := reduce([]string{"b", "a"})
+	require.Len(t, errs, MaxValidationErrors)
+	assert.Equal(t, "a", errs[0].Key)
+	assert.Equal(t, "default/a-00", errs[0].ConfigMap)
+	assert.Equal(t, "default/a-19", errs[MaxValidationErrors-1].ConfigMap)
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, errs, reduce([]string{"a", "b"}))
+	}
+}
+
+func TestReduceDataStateBlocksInvalidKeys(t *testing.T) {
+	mt := &MergeTarget{
+		Spec: MergeTargetSpec{Data: map[string]MergeTargetDataSpec{
+			"mapRoles": {JSONSchema: `{"type":"array","maxItems":2}`},
+		}},
+		Status: MergeTargetStatus{Data: map[string]MergeTargetDataStatus{"mapRoles": {Init: "- rolearn: init\n"}}},
+	}
+	contributions := []Contribution{
+		contribution("a", "- rolearn: a\n"),
+		contribution("b", "- rolearn: b\n"),
+		dryRunContribution("dry", "- rolearn: dry\n"),
+	}
+
+	data := map[string]string{"mapRoles": "- rolearn: init\n"}
+	_, updated, fieldsErrors, _ := mt.ReduceDataState(Documents{}, contributions, LastKnownGood{}, &data)
+	assert.Zero(t, updated)
+	assert.Len(t, fieldsErrors, 1)
+	assert.Equal(t, "- rolearn: init\n", data["mapRoles"])
+
+	// the violation of the whole value can't be attributed to any of them.
+	assert.Empty(t, rejectedNames(contributions))
+	assert.Equal(t, "the merged value of the key is invalid", contributions[0].Blocked)
+	assert.Equal(t, "the merged value of the key is invalid", contributions[1].Blocked)
+	assert.Empty(t, contributions[2].Blocked)
+}
+
+func TestReduceDataStateSchemaWarnings(t *testing.T) {
+	mt := &MergeTarget{
+		Spec: MergeTargetSpec{Data: map[string]MergeTargetDataSpec{
+			"mapRoles": {
+				JSONSchema:  `{"type":"array","items":{"required":["rolearn"]}}`,
+				Enforcement: SchemaEnforcementWarn,
+			},
+			"mapUsers": {},
+		}},
+		Status: MergeTargetStatus{Data: map[string]MergeTargetDataStatus{"mapRoles": {}, "mapUsers": {}}},
+	}
+	contributions := []Contribution{
+		contribution("a", "- username: a\n- username: b\n"),
+		contribution("b", "- rolearn: c\n- username: d\n"),
+	}
+
+	data := map[string]string{}
+	_, _, _, warnings := mt.ReduceDataState(Documents{}, contributions, LastKnownGood{}, &data)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "mapRoles", warnings[0].Key)
+	assert.Len(t, warnings[0].Errors, 1)
+	assert.Equal(t, 3, warnings[0].Violations)
+	assert.Equal(t, "- username: a\n- username: b\n- rolearn: c\n- username: d\n", data["mapRoles"])
+}
+
+// BenchmarkReconcile runs the merge, the validation of the data and the
+// entries of the outcomes of a reconciliation, over a large aws-auth mapRoles
+// contributed by many sources, each validated on its own.
+func BenchmarkReconcile(b *testing.B) {
+	mt := &MergeTarget{
+		Spec: MergeTargetSpec{
+			Schema: `{"type":"object","required":["mapRoles"]}`,
+			Data: map[string]MergeTargetDataSpec{"mapRoles": {
+				JSONSchema:     `{"type":"array","maxItems":5000}`,
+				ItemSchema:     `{"type":"object","required":["rolearn","username"],"properties":{"groups":{"type":"array"}}}`,
+				InvalidSources: InvalidSourcePolicyExclude,
+			}},
+		},
+		Status: MergeTargetStatus{Data: map[string]MergeTargetDataStatus{"mapRoles": {}}},
+	}
+
+	var contributions []Contribution
+	for s := 0; s < 100; s++ {
+		var data string
+		for r := 0; r < 20; r++ {
+			data += fmt.Sprintf(
+				"- rolearn: arn:aws:iam::111122223333:role/team-%d-role-%d\n  username: team-%d-%d\n  groups:\n  - system:masters\n",
+				s, r, s, r,
+			)
+		}
+
+		contributions = append(contributions, contribution(fmt.Sprintf("team-%d", s), data))
+	}
+
+	reconcile := func(b *testing.B, docs func() Documents) {
+		mt := mt.DeepCopy()
+		contributions := append([]Contribution(nil), contributions...)
+
+		data := map[string]string{}
+		if _, _, fieldsErrors, _ := mt.ReduceDataState(docs(), contributions, LastKnownGood{}, &data); len(fieldsErrors) > 0 {
+			b.Fatal(fieldsErrors)
+		} else if err := mt.ValidateData(docs(), data); err != nil {
+			b.Fatal(err)
+		}
+
+		d := docs()
+		for _, c := range contributions {
+			d.Items(c.Data)
+		}
+	}
+
+	for _, bc := range []struct {
+		name      string
+		cacheSize int
+		shared    bool
+	}{
+		{"uncached schemas", 0, true},
+		{"documents parsed by each step", validator.DefaultCacheSize, false},
+		{"cached", validator.DefaultCacheSize, true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			validator.SetCache(validator.NewSchemaCache(bc.cacheSize, nil))
+			defer validator.SetCache(validator.NewSchemaCache(validator.DefaultCacheSize, nil))
+
+			for i := 0; i < b.N; i++ {
+				docs := Documents{}
+				reconcile(b, func() Documents {
+					if !bc.shared {
+						return Documents{}
+					}
+
+					return docs
+				})
+			}
+		})
+	}
+}
@@ -195,9 +195,34 @@ func (in *MergeTarget) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MergeTargetDataPath) DeepCopyInto(out *MergeTargetDataPath) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeTargetDataPath.
+func (in *MergeTargetDataPath) DeepCopy() *MergeTargetDataPath {
+	if in == nil {
+		return nil
+	}
+	out := new(MergeTargetDataPath)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MergeTargetDataSpec) DeepCopyInto(out *MergeTargetDataSpec) {
 	*out = *in
+	if in.InitFrom != nil {
+		in, out := &in.InitFrom, &out.InitFrom
+		*out = new(MergeTargetInitFrom)
+		**out = **in
+	}
+	if in.RequiredEntries != nil {
+		in, out := &in.RequiredEntries, &out.RequiredEntries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeTargetDataSpec.
@@ -213,6 +238,13 @@ func (in *MergeTargetDataSpec) DeepCopy() *MergeTargetDataSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MergeTargetDataStatus) DeepCopyInto(out *MergeTargetDataStatus) {
 	*out = *in
+	if in.LastKnownGoodDigests != nil {
+		in, out := &in.LastKnownGoodDigests, &out.LastKnownGoodDigests
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeTargetDataStatus.
@@ -225,6 +257,46 @@ func (in *MergeTargetDataStatus) DeepCopy() *MergeTargetDataStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MergeTargetInitFrom) DeepCopyInto(out *MergeTargetInitFrom) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeTargetInitFrom.
+func (in *MergeTargetInitFrom) DeepCopy() *MergeTargetInitFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(MergeTargetInitFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MergeTargetInvariant) DeepCopyInto(out *MergeTargetInvariant) {
+	*out = *in
+	if in.Unique != nil {
+		in, out := &in.Unique, &out.Unique
+		*out = make([]MergeTargetDataPath, len(*in))
+		copy(*out, *in)
+	}
+	if in.References != nil {
+		in, out := &in.References, &out.References
+		*out = new(MergeTargetReferences)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeTargetInvariant.
+func (in *MergeTargetInvariant) DeepCopy() *MergeTargetInvariant {
+	if in == nil {
+		return nil
+	}
+	out := new(MergeTargetInvariant)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MergeTargetList) DeepCopyInto(out *MergeTargetList) {
 	*out = *in
@@ -257,6 +329,56 @@ func (in *MergeTargetList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MergeTargetReferences) DeepCopyInto(out *MergeTargetReferences) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]MergeTargetDataPath, len(*in))
+		copy(*out, *in)
+	}
+	if in.Defined != nil {
+		in, out := &in.Defined, &out.Defined
+		*out = make([]MergeTargetDataPath, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeTargetReferences.
+func (in *MergeTargetReferences) DeepCopy() *MergeTargetReferences {
+	if in == nil {
+		return nil
+	}
+	out := new(MergeTargetReferences)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MergeTargetRemovalGuard) DeepCopyInto(out *MergeTargetRemovalGuard) {
+	*out = *in
+	if in.MaxRemovedEntries != nil {
+		in, out := &in.MaxRemovedEntries, &out.MaxRemovedEntries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxRemovedPercent != nil {
+		in, out := &in.MaxRemovedPercent, &out.MaxRemovedPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeTargetRemovalGuard.
+func (in *MergeTargetRemovalGuard) DeepCopy() *MergeTargetRemovalGuard {
+	if in == nil {
+		return nil
+	}
+	out := new(MergeTargetRemovalGuard)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MergeTargetSpec) DeepCopyInto(out *MergeTargetSpec) {
 	*out = *in
@@ -264,9 +386,31 @@ func (in *MergeTargetSpec) DeepCopyInto(out *MergeTargetSpec) {
 		in, out := &in.Data, &out.Data
 		*out = make(map[string]MergeTargetDataSpec, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Invariants != nil {
+		in, out := &in.Invariants, &out.Invariants
+		*out = make([]MergeTargetInvariant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RollbackTo != nil {
+		in, out := &in.RollbackTo, &out.RollbackTo
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RemovalGuard != nil {
+		in, out := &in.RemovalGuard, &out.RemovalGuard
+		*out = new(MergeTargetRemovalGuard)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeTargetSpec.
@@ -286,7 +430,7 @@ func (in *MergeTargetStatus) DeepCopyInto(out *MergeTargetStatus) {
 		in, out := &in.Data, &out.Data
 		*out = make(map[string]MergeTargetDataStatus, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 	if in.Conditions != nil {
@@ -296,6 +440,47 @@ func (in *MergeTargetStatus) DeepCopyInto(out *MergeTargetStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.HandedOver != nil {
+		in, out := &in.HandedOver, &out.HandedOver
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.HandledRequests != nil {
+		in, out := &in.HandledRequests, &out.HandledRequests
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ValidationErrors != nil {
+		in, out := &in.ValidationErrors, &out.ValidationErrors
+		*out = make([]MergeTargetValidationError, len(*in))
+		copy(*out, *in)
+	}
+	if in.Contributions != nil {
+		in, out := &in.Contributions, &out.Contributions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SuspendedSources != nil {
+		in, out := &in.SuspendedSources, &out.SuspendedSources
+		*out = make(map[string][]SuspendedContribution, len(*in))
+		for key, val := range *in {
+			var outVal []SuspendedContribution
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]SuspendedContribution, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeTargetStatus.
@@ -307,3 +492,33 @@ func (in *MergeTargetStatus) DeepCopy() *MergeTargetStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MergeTargetValidationError) DeepCopyInto(out *MergeTargetValidationError) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeTargetValidationError.
+func (in *MergeTargetValidationError) DeepCopy() *MergeTargetValidationError {
+	if in == nil {
+		return nil
+	}
+	out := new(MergeTargetValidationError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SuspendedContribution) DeepCopyInto(out *SuspendedContribution) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SuspendedContribution.
+func (in *SuspendedContribution) DeepCopy() *SuspendedContribution {
+	if in == nil {
+		return nil
+	}
+	out := new(SuspendedContribution)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -7,6 +7,20 @@ import (
 const (
 	watchedBy            annotations.Annotation = "config.cmmc.k8s.cash.app/watched-by-merge-source"
 	managedByMergeTarget annotations.Annotation = "config.cmmc.k8s.cash.app/managed-by-merge-target"
+	managedKeys          annotations.Annotation = "config.cmmc.k8s.cash.app/managed-keys"
+	handoverFrom         annotations.Annotation = "config.cmmc.k8s.cash.app/handover-from"
+	handoverTo           annotations.Annotation = "config.cmmc.k8s.cash.app/handover-to"
+	handoverState        annotations.Annotation = "config.cmmc.k8s.cash.app/handover-state"
+	stateOf              annotations.Annotation = "config.cmmc.k8s.cash.app/state-of"
+	lastKnownGoodOf      annotations.Annotation = "config.cmmc.k8s.cash.app/last-known-good-of"
+	rebaselineRequest    annotations.Annotation = "config.cmmc.k8s.cash.app/rebaseline"
+	acknowledgeDrift     annotations.Annotation = "config.cmmc.k8s.cash.app/acknowledge-drift"
+	acknowledgeRemoval   annotations.Annotation = "config.cmmc.k8s.cash.app/acknowledge-removal"
+	dryRunOf             annotations.Annotation = "config.cmmc.k8s.cash.app/dry-run-of"
+	dryRunTarget         annotations.Annotation = "config.cmmc.k8s.cash.app/dry-run-target"
+	reconcileRequest     annotations.Annotation = "reconcile.cmmc.k8s.cash.app/requestedAt"
+	contributionStatus   annotations.Annotation = "config.cmmc.k8s.cash.app/contribution-status"
+	dryRunSource         annotations.Annotation = "config.cmmc.k8s.cash.app/dry-run"
 )
 
 const (
@@ -0,0 +1,256 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cashapp/cmmc/util"
+	"github.com/cashapp/cmmc/util/managedfields"
+)
+
+const (
+	// fieldManager is the field manager cmmc writes with, apart from the data
+	// of target ConfigMaps which every MergeTarget applies as its own field
+	// manager (see targetFieldManager).
+	fieldManager = "cmmc"
+
+	// maxFieldManagerLength is the maximum length of a field manager name.
+	maxFieldManagerLength = 128
+
+	// baselineFieldManager owns the values that keys are reverted to when cmmc
+	// stops managing them, so that they are not removed with cmmc's ownership.
+	// It only owns them until the keys are released (see dropBaseline).
+	baselineFieldManager = "cmmc-baseline"
+)
+
+// targetFieldManager is the field manager the data of the MergeTarget is applied as.
+func targetFieldManager(mtName string) string {
+	manager := fieldManager + ":" + mtName
+	if len(manager) > maxFieldManagerLength {
+		manager = fieldManager + ":" + util.Digest(mtName)
+	}
+
+	return manager
+}
+
+// isTargetFieldManager checks if the manager is the field manager of a MergeTarget.
+func isTargetFieldManager(manager string) bool {
+	return strings.HasPrefix(manager, fieldManager+":")
+}
+
+// targetApplyConfig is what a field manager applies to a target ConfigMap.
+type targetApplyConfig struct {
+	Data        map[string]string
+	Annotations map[string]string
+}
+
+// applyTarget server-side applies the config to the target ConfigMap as manager,
+// and updates cm with the result.
+//
+// The apply is conditional on the resourceVersion of cm. Fields that are owned
+// by other managers, but not yet by manager, are adopted (forced), unless they
+// are owned by another MergeTarget. Any other conflicts are returned as a
+// *fieldConflictError.
+func applyTarget(
+	ctx context.Context, c client.Client, cm *corev1.ConfigMap, manager string, config targetApplyConfig,
+) error {
+	apply := func(force bool) error {
+		obj := &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            cm.Name,
+				Namespace:       cm.Namespace,
+				ResourceVersion: cm.ResourceVersion,
+				Annotations:     config.Annotations,
+			},
+			Data: config.Data,
+		}
+
+		opts := []client.PatchOption{client.FieldOwner(manager)}
+		if force {
+			opts = append(opts, client.ForceOwnership)
+		}
+
+		if err := c.Patch(ctx, obj, client.Apply, opts...); err != nil {
+			return errors.WithStack(err)
+		}
+
+		*cm = *obj
+		return nil
+	}
+
+	err := apply(false)
+	conflicts := fieldConflicts(err)
+	if len(conflicts) == 0 {
+		return err
+	}
+
+	for _, conflict := range conflicts {
+		if isOwnedBy(cm, manager, conflict.Field) || isOwnedByOtherTarget(cm, manager, conflict.Field) {
+			return &fieldConflictError{Conflicts: conflicts, Owners: conflictOwners(cm, manager, conflicts)}
+		}
+	}
+
+	return apply(true)
+}
+
+// fieldConflictError is returned when applying a target conflicts with another field manager.
+type fieldConflictError struct {
+	Conflicts []metav1.StatusCause
+
+	// Owners are the owners of the conflicting fields, MergeTargets are
+	// referred to by name, any other field manager by its manager name.
+	Owners []string
+}
+
+func (e *fieldConflictError) Error() string {
+	msgs := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		msgs[i] = c.Message
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// fieldConflicts returns the field manager conflicts of a failed apply.
+func fieldConflicts(err error) []metav1.StatusCause {
+	var status apierrors.APIStatus
+	if !apierrors.IsConflict(err) || !errors.As(err, &status) || status.Status().Details == nil {
+		return nil
+	}
+
+	var conflicts []metav1.StatusCause
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type == metav1.CauseTypeFieldManagerConflict {
+			conflicts = append(conflicts, cause)
+		}
+	}
+
+	return conflicts
+}
+
+// isOwnedBy checks if the manager applied the field, given as it is in a
+// conflict, e.g. ".data.mapRoles".
+func isOwnedBy(cm *corev1.ConfigMap, manager, field string) bool {
+	return managedfields.IsOwnedBy(cm, manager, metav1.ManagedFieldsOperationApply, fieldPath(field)...)
+}
+
+// isOwnedByOtherTarget checks if a MergeTarget other than manager owns the field.
+func isOwnedByOtherTarget(cm *corev1.ConfigMap, manager, field string) bool {
+	for _, owner := range managedfields.Owners(cm, fieldPath(field)...) {
+		if owner.Manager != manager && isTargetFieldManager(owner.Manager) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// conflictOwners returns the owners (other than manager) of the conflicting fields.
+func conflictOwners(cm *corev1.ConfigMap, manager string, conflicts []metav1.StatusCause) []string {
+	var owners []string
+	for _, conflict := range conflicts {
+		for _, owner := range managedfields.Owners(cm, fieldPath(conflict.Field)...) {
+			name := owner.Manager
+			if name == manager {
+				continue
+			} else if isTargetFieldManager(name) {
+				name = "MergeTarget " + strings.TrimPrefix(name, fieldManager+":")
+			}
+
+			if !util.ContainsString(owners, name) {
+				owners = append(owners, name)
+			}
+		}
+	}
+
+	sort.Strings(owners)
+	return owners
+}
+
+// fieldPath splits a field, as it is in a conflict, into its path.
+func fieldPath(field string) []string {
+	switch {
+	case strings.HasPrefix(field, ".data."):
+		return []string{"data", strings.TrimPrefix(field, ".data.")}
+	case strings.HasPrefix(field, ".metadata.annotations."):
+		return []string{"metadata", "annotations", strings.TrimPrefix(field, ".metadata.annotations.")}
+	default:
+		return strings.Split(strings.TrimPrefix(field, "."), ".")
+	}
+}
+
+// applyBaseline hands the keys over to the baseline field manager with the given values.
+//
+// Every key the baseline field manager already owns is applied again with its
+// current value, since leaving it out of the apply would remove it.
+func applyBaseline(ctx context.Context, c client.Client, cm *corev1.ConfigMap, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	data := map[string]string{}
+	for _, k := range managedfields.Fields(cm, baselineFieldManager, metav1.ManagedFieldsOperationApply, "data") {
+		if v, ok := cm.Data[k]; ok {
+			data[k] = v
+		}
+	}
+
+	for k, v := range values {
+		data[k] = v
+	}
+
+	return applyTarget(ctx, c, cm, baselineFieldManager, targetApplyConfig{Data: data})
+}
+
+// dropBaseline removes the baseline field manager from the managed fields of
+// the ConfigMap, once the keys it took over are released. Their values are
+// kept, they are just no longer owned by cmmc.
+//
+// The patch is conditional on the resourceVersion, so that the baseline of a
+// concurrent release isn't dropped before it is released.
+func dropBaseline(ctx context.Context, c client.Client, cm *corev1.ConfigMap) error {
+	var kept []metav1.ManagedFieldsEntry
+	for _, entry := range cm.GetManagedFields() {
+		if entry.Manager != baselineFieldManager {
+			kept = append(kept, entry)
+		}
+	}
+
+	if len(kept) == len(cm.GetManagedFields()) {
+		return nil
+	} else if len(kept) == 0 {
+		// an empty list leaves the managed fields as they are, a single empty
+		// entry clears them.
+		kept = []metav1.ManagedFieldsEntry{{}}
+	}
+
+	base := cm.DeepCopy()
+	cm.SetManagedFields(kept)
+
+	return errors.WithStack(c.Patch(
+		ctx, cm, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}), client.FieldOwner(fieldManager),
+	))
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
+	"github.com/cashapp/cmmc/util"
+	anns "github.com/cashapp/cmmc/util/annotations"
+)
+
+// The outcomes of a contribution, as reported to its source ConfigMap.
+//
+// The outcomes of dry-run contributions, which are never merged, are
+// prefixed with dryRunOutcomePrefix.
+const (
+	contributionAccepted   = "accepted"
+	contributionRejected   = "rejected"
+	contributionBlocked    = "blocked"
+	contributionDuplicated = "duplicated"
+
+	dryRunOutcomePrefix = "dry-run-"
+)
+
+// contributionReasons are the reasons of the events reporting each outcome.
+var contributionReasons = map[string]string{
+	contributionAccepted:   "ContributionAccepted",
+	contributionRejected:   "ContributionRejected",
+	contributionBlocked:    "ContributionBlocked",
+	contributionDuplicated: "ContributionDuplicated",
+}
+
+// reportContributions tells the source ConfigMaps what happened to their
+// contributions: with an event in their namespace whenever the outcome changes,
+// and with the contribution-status annotation if their MergeSource annotates
+// its sources. App teams can usually see neither the MergeSource nor the
+// MergeTarget.
+//
+// Sources that no longer contribute have their annotation entry removed.
+func (r *MergeTargetReconciler) reportContributions(
+	ctx context.Context, mtName string, mt *MergeTarget, docs cmmcv1beta1.Documents, contributions []cmmcv1beta1.Contribution,
+) error {
+	var (
+		reported = map[string]string{}
+		merged   = map[string]map[string]struct{}{}
+	)
+
+	for _, c := range contributions {
+		outcome, message := contributionOutcome(mt, docs, c, merged)
+		id := c.Key + "/" + c.ConfigMap.String()
+
+		reported[id] = outcome
+		if c.DryRun {
+			reported[id] = dryRunOutcomePrefix + outcome
+			message = "(dry run, not merged) " + message
+		}
+
+		if previous, ok := mt.Status.Contributions[id]; !ok || previous != reported[id] {
+			eventType := corev1.EventTypeNormal
+			if outcome == contributionRejected || outcome == contributionBlocked {
+				eventType = corev1.EventTypeWarning
+			}
+
+			source := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: c.ConfigMap.Name, Namespace: c.ConfigMap.Namespace, UID: c.UID},
+			}
+			r.EventRecorder.Eventf(source, eventType, contributionReasons[outcome],
+				"Contribution to %s of MergeTarget %s %s", c.Key, mtName, message)
+		}
+
+		if !c.AnnotateSource {
+			continue
+		}
+
+		if err := r.annotateContribution(ctx, c.ConfigMap, mtName, c.Key, reported[id]); err != nil {
+			return err
+		}
+	}
+
+	for id := range mt.Status.Contributions {
+		if _, ok := reported[id]; ok {
+			continue
+		}
+
+		parts := strings.SplitN(id, "/", 2)
+		name, err := util.NamespacedName(parts[len(parts)-1], "")
+		if err != nil {
+			continue
+		}
+
+		if err := r.annotateContribution(ctx, name, mtName, parts[0], ""); err != nil {
+			return err
+		}
+	}
+
+	if len(reported) == 0 {
+		reported = nil
+	}
+
+	mt.Status.Contributions = reported
+	return nil
+}
+
+// contributionOutcome is the outcome of the contribution, and its description.
+//
+// A valid contribution that isn't written to the target (see
+// Contribution.Blocked) is blocked. A contribution whose entries were all
+// merged before (by the initial value of the key, or by earlier contributions)
+// is duplicated. The entries of dry-run contributions don't count as merged.
+// Values are parsed with docs.
+func contributionOutcome(
+	mt *MergeTarget, docs cmmcv1beta1.Documents, c cmmcv1beta1.Contribution, merged map[string]map[string]struct{},
+) (string, string) {
+	if c.Rejected != "" {
+		return contributionRejected, "rejected: " + c.Rejected
+	} else if c.Blocked != "" {
+		return contributionBlocked, "blocked: " + c.Blocked
+	}
+
+	seen, ok := merged[c.Key]
+	if !ok {
+		seen = map[string]struct{}{}
+		for _, e := range docs.Items(mt.Status.Data[c.Key].Init) {
+			seen[e] = struct{}{}
+		}
+		merged[c.Key] = seen
+	}
+
+	added := docs.Items(c.Data)
+	duplicated := len(added) > 0
+	for _, e := range added {
+		if _, ok := seen[e]; !ok {
+			duplicated = false
+			if !c.DryRun {
+				seen[e] = struct{}{}
+			}
+		}
+	}
+
+	if duplicated {
+		return contributionDuplicated, "duplicates entries that were already merged"
+	}
+
+	return contributionAccepted, "accepted"
+}
+
+// annotateContribution sets the outcome of the contribution of the source
+// ConfigMap to the key in its contribution-status annotation, which lists the
+// outcomes as <namespace>/<MergeTarget>/<key>=<outcome>. An empty outcome
+// removes it.
+func (r *MergeTargetReconciler) annotateContribution(
+	ctx context.Context, name types.NamespacedName, mtName, key, outcome string,
+) error {
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, name, &cm); err != nil {
+		return errors.Wrapf(client.IgnoreNotFound(err), "failed fetching source configMap %s", name)
+	}
+
+	var (
+		prefix = fmt.Sprintf("%s/%s=", mtName, key)
+		fns    []anns.UpdateFn
+	)
+
+	for _, entry := range strings.Split(cm.GetAnnotations()[contributionStatus.String()], ",") {
+		if strings.HasPrefix(entry, prefix) && entry != prefix+outcome {
+			fns = append(fns, contributionStatus.RemoveFromList(entry))
+		}
+	}
+
+	if outcome != "" {
+		fns = append(fns, contributionStatus.AddToList(prefix+outcome))
+	}
+
+	return errors.Wrapf(
+		anns.Patch(ctx, r.Client, &cm, fieldManager, fns...), "failed annotating source configMap %s", name,
+	)
+}
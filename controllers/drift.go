@@ -0,0 +1,141 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
+	"github.com/cashapp/cmmc/util"
+	"github.com/cashapp/cmmc/util/managedfields"
+)
+
+// drift is an out-of-band modification of a key managed by a MergeTarget.
+type drift struct {
+	Key     string
+	Digest  string
+	Writers []string
+}
+
+type drifts []drift
+
+func (d drifts) Keys() []string {
+	keys := make([]string, 0, len(d))
+	for _, v := range d {
+		keys = append(keys, v.Key)
+	}
+
+	return keys
+}
+
+// String formats the drifts as e.g. "mapRoles (by kubectl-edit), mapUsers (by unknown)".
+func (d drifts) String() string {
+	entries := make([]string, 0, len(d))
+	for _, v := range d {
+		entries = append(entries, fmt.Sprintf("%s (by %s)", v.Key, strings.Join(writerNames(v.Writers), ", ")))
+	}
+
+	return strings.Join(entries, ", ")
+}
+
+func writerNames(writers []string) []string {
+	if len(writers) == 0 {
+		return []string{"unknown"}
+	}
+
+	return writers
+}
+
+// detectDrift returns the managed keys of the target ConfigMap that were
+// modified by someone else since the MergeTarget last wrote them.
+//
+// A key drifted if its value isn't the one last written, and the MergeTarget
+// lost the ownership of the field: a manager updating the field takes it over.
+// Keys the MergeTarget never wrote aren't checked.
+func detectDrift(mtName string, mt *MergeTarget, cm *corev1.ConfigMap) drifts {
+	manager := targetFieldManager(mtName)
+
+	var found drifts
+	for k, status := range mt.Status.Data {
+		if _, ok := mt.Spec.Data[k]; !ok || status.AppliedDigest == "" {
+			continue
+		}
+
+		field := ".data." + k
+		if util.Digest(cm.Data[k]) == status.AppliedDigest || isOwnedBy(cm, manager, field) {
+			continue
+		}
+
+		var writers []string
+		for _, owner := range managedfields.Owners(cm, fieldPath(field)...) {
+			if owner.Manager != manager && owner.Manager != baselineFieldManager &&
+				!util.ContainsString(writers, owner.Manager) {
+				writers = append(writers, owner.Manager)
+			}
+		}
+
+		sort.Strings(writers)
+		found = append(found, drift{Key: k, Digest: util.Digest(cm.Data[k]), Writers: writers})
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Key < found[j].Key })
+	return found
+}
+
+// reportDrift sets the drifted condition of the MergeTarget, and emits an
+// event and records a metric for every modification that wasn't reported yet.
+func (r *MergeTargetReconciler) reportDrift(mt *MergeTarget, found drifts) {
+	if len(found) == 0 {
+		mt.RemoveStatusCondition(cmmcv1beta1.MergeTargetConditionTypeDrifted)
+		return
+	}
+
+	mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionDrifted(mt.Spec.DriftPolicy, found.String()))
+	for _, d := range found {
+		status := mt.Status.Data[d.Key]
+		if status.DriftedDigest == d.Digest {
+			continue
+		}
+
+		writers := writerNames(d.Writers)
+		for _, w := range writers {
+			r.Recorder.RecordDrift(mt, d.Key, w)
+		}
+
+		r.EventRecorder.Eventf(mt, corev1.EventTypeWarning, "Drifted",
+			"Key %s of the target was modified by %s", d.Key, strings.Join(writers, ", "))
+
+		status.DriftedDigest = d.Digest
+		mt.Status.Data[d.Key] = status
+	}
+}
+
+// keptDrift returns the drifted keys the MergeTarget keeps rather than
+// reverting, the ones it would still write the value it last wrote to.
+func keptDrift(mt *MergeTarget, found drifts, data map[string]string) []string {
+	var kept []string
+	for _, d := range found {
+		if util.Digest(data[d.Key]) == mt.Status.Data[d.Key].AppliedDigest {
+			kept = append(kept, d.Key)
+		}
+	}
+
+	return kept
+}
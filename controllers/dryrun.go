@@ -0,0 +1,141 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
+	"github.com/cashapp/cmmc/util"
+	"github.com/cashapp/cmmc/util/diff"
+)
+
+const (
+	dryRunConfigMapSuffix = "-cmmc-dry-run"
+
+	// dryRunDiffKey is the key of the dry-run ConfigMap holding the diff against
+	// the target, which can be too large for an annotation.
+	dryRunDiffKey = "cmmc-dry-run.diff"
+)
+
+// dryRun merges the data of the MergeTarget like reconcileMergeTarget does, but
+// writes the would-be data of the target ConfigMap, and its diff, to the dry-run
+// ConfigMap instead of the target.
+//
+// The target ConfigMap is only read, and nothing about it is recorded in the status.
+func (r *MergeTargetReconciler) dryRun(
+	ctx context.Context, mtName string, mt *MergeTarget, targetName types.NamespacedName,
+	lastKnownGood cmmcv1beta1.LastKnownGood,
+) error {
+	contributions, suspended, numMergeSources, err := r.contributions(ctx, mtName)
+	if err != nil {
+		return err
+	}
+
+	r.Recorder.RecordNumSources(mt, numMergeSources)
+	inits, err := r.initFromValues(ctx, mt)
+	if err != nil {
+		mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionInitFromMissing(err))
+		log.FromContext(ctx).Info("failed loading initial values", "error", err.Error())
+		return nil
+	}
+
+	var target corev1.ConfigMap
+	if err := r.targetReader().Get(ctx, targetName, &target); client.IgnoreNotFound(err) != nil {
+		return errors.Wrap(err, "failed fetching target configMap")
+	}
+
+	if token, ok := pendingRequest(mt, reconcileRequest); ok {
+		markRequestHandled(mt, reconcileRequest, token)
+	}
+
+	// merge using a copy of the MergeTarget, to leave its status alone.
+	docs, merged := cmmcv1beta1.Documents{}, mt.DeepCopy()
+	if merged.Status.Target != targetName.String() {
+		merged.ResetStatus(targetName.String())
+	}
+
+	data := make(map[string]string, len(target.Data))
+	for k, v := range target.Data {
+		data[k] = v
+	}
+
+	contributions = merged.FreezeContributions(contributions, suspended)
+	merged.UpdateDataStatus(target.Data)
+	merged.SetInits(inits)
+	_, _, fieldsErrorMsgs, schemaWarnings := merged.ReduceDataState(docs, contributions, lastKnownGood, &data)
+	r.guardRemovals(ctx, mt, target.Data, data, true)
+	r.refuseMissingRequired(ctx, mt, target.Data, data)
+
+	changedKeys := diff.ChangedKeys(target.Data, data)
+	dryRunData := make(map[string]string, len(data)+1)
+	for k, v := range data {
+		dryRunData[k] = v
+	}
+
+	dryRunData[dryRunDiffKey] = diff.Maps(target.Data, data)
+
+	name := companionName(mt, dryRunConfigMapSuffix)
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Annotations: map[string]string{
+				dryRunOf.String():     util.ObjectResourceName(mt),
+				dryRunTarget.String(): targetName.String(),
+			},
+		},
+		Data: dryRunData,
+	}
+
+	if err := controllerutil.SetControllerReference(mt, cm, r.Scheme); err != nil {
+		return errors.WithStack(err)
+	} else if err := r.Patch(ctx, cm, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
+		return errors.Wrap(err, "failed writing dry-run configMap")
+	}
+
+	mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionValidation(fieldsErrorMsgs, numMergeSources))
+	r.reportSchemaWarnings(mt, schemaWarnings)
+	r.reportSchemaViolation(mt, merged.ValidateData(docs, data))
+	mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionDryRun(name.String(), changedKeys))
+	return nil
+}
+
+// endDryRun deletes the dry-run ConfigMap, once the MergeTarget is no longer
+// in dry-run mode.
+func (r *MergeTargetReconciler) endDryRun(ctx context.Context, mt *MergeTarget) error {
+	if mt.FindStatusCondition(cmmcv1beta1.MergeTargetConditionTypeDryRun) == nil {
+		return nil
+	}
+
+	name := companionName(mt, dryRunConfigMapSuffix)
+	err := r.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace}})
+	if client.IgnoreNotFound(err) != nil {
+		return errors.Wrap(err, "failed deleting dry-run configMap")
+	}
+
+	mt.RemoveStatusCondition(cmmcv1beta1.MergeTargetConditionTypeDryRun)
+	return nil
+}
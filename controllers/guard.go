@@ -0,0 +1,115 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
+	"github.com/cashapp/cmmc/util"
+	"github.com/cashapp/cmmc/util/diff"
+	"github.com/cashapp/cmmc/util/entries"
+)
+
+// guardRemovals holds the current values of the keys that would lose more
+// entries than the removal guard of the MergeTarget allows, and returns them.
+//
+// A removal is let through once it is acknowledged with a new token, the
+// acknowledgement is used up by the next reconcile either way. A preview (dry
+// run) honours the acknowledgement without using it up.
+func (r *MergeTargetReconciler) guardRemovals(
+	ctx context.Context, mt *MergeTarget, live, data map[string]string, preview bool,
+) []string {
+	token, acknowledged := pendingRequest(mt, acknowledgeRemoval)
+	if acknowledged && !preview {
+		markRequestHandled(mt, acknowledgeRemoval, token)
+	}
+
+	var blocked, removals []string
+	for _, k := range diff.ChangedKeys(live, data) {
+		// keys removed from the spec are reverted on purpose.
+		if _, ok := mt.Spec.Data[k]; !ok {
+			continue
+		}
+
+		if removed, total := entries.Removed(live[k], data[k]); mt.Spec.RemovalGuard.Blocks(removed, total) {
+			blocked = append(blocked, k)
+			removals = append(removals, fmt.Sprintf("%s: %d of %d entries", k, removed, total))
+		}
+	}
+
+	if len(blocked) == 0 {
+		mt.RemoveStatusCondition(cmmcv1beta1.MergeTargetConditionTypeBlocked)
+		return nil
+	} else if acknowledged {
+		log.FromContext(ctx).Info("removal acknowledged", "keys", blocked)
+		r.EventRecorder.Eventf(mt, corev1.EventTypeNormal, "RemovalAcknowledged", "Removing entries of %s", removals)
+		mt.RemoveStatusCondition(cmmcv1beta1.MergeTargetConditionTypeBlocked)
+		return nil
+	}
+
+	log.FromContext(ctx).Info("blocking removal of too many entries", "keys", blocked)
+	r.EventRecorder.Eventf(mt, corev1.EventTypeWarning, "Blocked",
+		"Holding the previous value of keys losing too many entries at once: %s", removals)
+	mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionBlocked(removals))
+
+	keepValues(blocked, live, data)
+	return blocked
+}
+
+// refuseMissingRequired holds the current values of the keys whose merged
+// value is missing any of their required entries, and returns them.
+func (r *MergeTargetReconciler) refuseMissingRequired(
+	ctx context.Context, mt *MergeTarget, live, data map[string]string,
+) []string {
+	var refused, missing []string
+	for _, k := range diff.ChangedKeys(live, data) {
+		spec, ok := mt.Spec.Data[k]
+		if !ok {
+			continue
+		}
+
+		for _, e := range spec.RequiredEntries {
+			if !entries.Contains(data[k], e) {
+				if !util.ContainsString(refused, k) {
+					refused = append(refused, k)
+				}
+
+				missing = append(missing, k+": "+strings.Join(strings.Fields(e), " "))
+			}
+		}
+	}
+
+	if len(refused) == 0 {
+		mt.RemoveStatusCondition(cmmcv1beta1.MergeTargetConditionTypeRequiredEntryMissing)
+		return nil
+	}
+
+	sort.Strings(missing)
+	log.FromContext(ctx).Info("refusing merged values missing required entries", "keys", refused)
+	r.EventRecorder.Eventf(mt, corev1.EventTypeWarning, "RequiredEntryMissing",
+		"Not writing merged values missing required entries: %s", strings.Join(missing, "; "))
+	mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionRequiredEntryMissing(missing))
+
+	keepValues(refused, live, data)
+	return refused
+}
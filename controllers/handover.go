@@ -0,0 +1,195 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
+	anns "github.com/cashapp/cmmc/util/annotations"
+	"github.com/cashapp/cmmc/util/managedfields"
+)
+
+// handoverStatus is the status of the keys handed over from one MergeTarget to
+// another, stored on the target ConfigMap until the new owner accepted them.
+type handoverStatus struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	// NewlyCreated is set if the ConfigMap was created by the previous owner,
+	// and it handed over all of its keys.
+	NewlyCreated string `json:"newlyCreated,omitempty"`
+
+	Data map[string]cmmcv1beta1.MergeTargetDataStatus `json:"data"`
+}
+
+func parseHandoverStatus(cm *corev1.ConfigMap) (handoverStatus, bool) {
+	var status handoverStatus
+	v, ok := cm.GetAnnotations()[handoverState.String()]
+	if !ok || json.Unmarshal([]byte(v), &status) != nil {
+		return handoverStatus{}, false
+	}
+
+	return status, true
+}
+
+// handOver hands the keys of the MergeTarget over to the MergeTarget it names
+// in its handover-to annotation, if that one asks for them with its
+// handover-from annotation.
+//
+// The handed over keys keep their values and their status: the new owner is
+// made a co-owner of the fields first, then the key ownership and the status
+// are moved, and finally the MergeTarget gives up its ownership of the fields.
+func (r *MergeTargetReconciler) handOver(
+	ctx context.Context, mtName string, mt *MergeTarget, cm *corev1.ConfigMap, saveState func() error,
+) error {
+	to, ok := handoverTo.ParseObjectName(mt)
+	if !ok {
+		return nil
+	}
+
+	var next MergeTarget
+	if err := r.Get(ctx, to, &next); err != nil {
+		return errors.Wrapf(client.IgnoreNotFound(err), "failed fetching MergeTarget %s", to)
+	}
+
+	if from, ok := handoverFrom.ParseObjectName(&next); !ok || from.String() != mtName {
+		return nil
+	} else if target, err := next.NamespacedTargetName(); err != nil || target.String() != cm.Namespace+"/"+cm.Name {
+		return nil
+	} else if status, ok := parseHandoverStatus(cm); ok && status.To != to.String() {
+		// another handover still has to be accepted, the ConfigMap changing
+		// will get us back here once it is.
+		return nil
+	}
+
+	var keys []string
+	for k := range mt.Status.Data {
+		if _, ok := next.Spec.Data[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	sort.Strings(keys)
+	log.FromContext(ctx).Info("handing over keys", "to", to.String(), "keys", keys)
+
+	// 1. make the new owner co-own the fields, with their current values.
+	nextManager := targetFieldManager(to.String())
+	data := map[string]string{}
+	for _, k := range managedfields.Fields(cm, nextManager, metav1.ManagedFieldsOperationApply, "data") {
+		data[k] = cm.Data[k]
+	}
+
+	for _, k := range keys {
+		if v, ok := cm.Data[k]; ok {
+			data[k] = v
+		}
+	}
+
+	if err := applyTarget(ctx, r.Client, cm, nextManager, targetApplyConfig{Data: data}); err != nil {
+		return errors.Wrap(err, "failed handing over fields")
+	}
+
+	// 2. move the key ownership, and the status of the keys.
+	status := handoverStatus{From: mtName, To: to.String(), Data: mt.HandOver(keys, to.String())}
+	if len(mt.Status.Data) == 0 && mt.IsStatusNewlyCreated() {
+		status.NewlyCreated = cmmcv1beta1.DataNewlyCreatedStatusYes
+	}
+
+	encoded, err := json.Marshal(status)
+	if err != nil {
+		return errors.WithStack(err)
+	} else if err := saveState(); err != nil {
+		return err
+	}
+
+	owners := parseKeyOwners(cm)
+	for _, k := range keys {
+		owners[k] = to.String()
+	}
+
+	fns := []anns.UpdateFn{
+		setKeyOwners(owners),
+		managedByMergeTarget.AddToList(to.String()),
+		anns.Add(handoverState.String(), string(encoded)),
+	}
+
+	if len(mt.Status.Data) == 0 {
+		fns = append(fns, managedByMergeTarget.RemoveFromList(mtName))
+	}
+
+	if err := patchOwnership(ctx, r.Client, cm, fns...); err != nil {
+		return errors.Wrap(err, "failed handing over keys")
+	}
+
+	// 3. stop owning the fields.
+	managed := map[string]string{}
+	for k := range mt.Status.Data {
+		if v, ok := cm.Data[k]; ok {
+			managed[k] = v
+		}
+	}
+
+	mt.Status.InputDigest = ""
+	return errors.Wrap(
+		applyTarget(ctx, r.Client, cm, targetFieldManager(mtName), targetApplyConfig{Data: managed}),
+		"failed releasing handed over fields",
+	)
+}
+
+// acceptHandover takes over the status of the keys handed over to the MergeTarget.
+//
+// The handover status is only removed from the ConfigMap once the keys are in
+// the (persisted) status of the MergeTarget.
+func acceptHandover(ctx context.Context, c client.Client, mtName string, mt *MergeTarget, cm *corev1.ConfigMap) error {
+	status, ok := parseHandoverStatus(cm)
+	if !ok || status.To != mtName {
+		return nil
+	}
+
+	if mt.AcceptHandover(status.Data, status.NewlyCreated) {
+		log.FromContext(ctx).Info("accepted handover", "from", status.From)
+		return nil
+	}
+
+	return patchOwnership(ctx, c, cm, handoverState.Remove())
+}
+
+// mergeTargetsForHandover maps a MergeTarget to the MergeTargets it is
+// handing over to, or asking a handover from.
+func mergeTargetsForHandover(o client.Object) []reconcile.Request {
+	var reqs []reconcile.Request
+	for _, a := range []anns.Annotation{handoverFrom, handoverTo} {
+		if n, ok := a.ParseObjectName(o); ok {
+			reqs = append(reqs, reconcile.Request{NamespacedName: n})
+		}
+	}
+
+	return reqs
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
+	"github.com/cashapp/cmmc/util"
+)
+
+// lastKnownGoodConfigMapSuffix is the suffix of the ConfigMap next to the
+// MergeTarget keeping the last valid contributions its status refers to (by
+// digest), which would bloat the status (and its backups).
+const lastKnownGoodConfigMapSuffix = "-cmmc-last-known-good"
+
+func lastKnownGoodConfigMapName(mt *MergeTarget) types.NamespacedName {
+	return companionName(mt, lastKnownGoodConfigMapSuffix)
+}
+
+// loadLastKnownGood reads the last valid contributions of the MergeTarget, and
+// returns them with the digest of what is currently saved.
+//
+// They are read from the cache, unless it misses some of the contributions
+// the status refers to. Those missing from the ConfigMap of the MergeTarget
+// are looked up in the one of the MergeTarget its keys are handed over from.
+func (r *MergeTargetReconciler) loadLastKnownGood(
+	ctx context.Context, mt *MergeTarget,
+) (cmmcv1beta1.LastKnownGood, string, error) {
+	var (
+		name   = lastKnownGoodConfigMapName(mt)
+		goods  = cmmcv1beta1.LastKnownGood{}
+		digest string
+	)
+
+	missing := func() bool {
+		for _, d := range mt.LastKnownGoodDigests() {
+			if _, ok := goods[d]; !ok {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, reader := range []client.Reader{r.Client, r.apiReader()} {
+		var cm corev1.ConfigMap
+		if err := reader.Get(ctx, name, &cm); client.IgnoreNotFound(err) != nil {
+			return nil, "", errors.Wrap(err, "failed fetching last known good configMap")
+		}
+
+		goods = cmmcv1beta1.LastKnownGood{}
+		for k, v := range cm.Data {
+			goods[k] = v
+		}
+
+		var err error
+		if digest, err = lastKnownGoodDigest(cm.Data); err != nil {
+			return nil, "", err
+		} else if !missing() {
+			return goods, digest, nil
+		}
+	}
+
+	if from, ok := handoverFrom.ParseObjectName(mt); ok {
+		var cm corev1.ConfigMap
+		previous := &MergeTarget{ObjectMeta: metav1.ObjectMeta{Name: from.Name, Namespace: from.Namespace}}
+		if err := r.apiReader().Get(ctx, lastKnownGoodConfigMapName(previous), &cm); client.IgnoreNotFound(err) != nil {
+			return nil, "", errors.Wrap(err, "failed fetching last known good configMap of the previous owner")
+		}
+
+		for _, d := range mt.LastKnownGoodDigests() {
+			if v, ok := cm.Data[d]; ok {
+				goods[d] = v
+			}
+		}
+	}
+
+	return goods, digest, nil
+}
+
+// saveLastKnownGood writes the last valid contributions the status of the
+// MergeTarget refers to to its ConfigMap, if they changed since digest, and
+// updates digest. The ConfigMap is deleted once there are none.
+//
+// While the MergeTarget hands its keys over, nothing is dropped, so that the
+// new owner can still find the contributions of the handed over keys.
+func (r *MergeTargetReconciler) saveLastKnownGood(
+	ctx context.Context, mt *MergeTarget, goods cmmcv1beta1.LastKnownGood, digest *string,
+) error {
+	data := map[string]string{}
+	for _, d := range mt.LastKnownGoodDigests() {
+		if v, ok := goods[d]; ok {
+			data[d] = v
+		}
+	}
+
+	if _, ok := handoverTo.ParseObjectName(mt); ok {
+		for d, v := range goods {
+			data[d] = v
+		}
+	}
+
+	newDigest, err := lastKnownGoodDigest(data)
+	if err != nil {
+		return err
+	} else if newDigest == *digest {
+		return nil
+	}
+
+	name := lastKnownGoodConfigMapName(mt)
+	if len(data) == 0 {
+		err := r.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace}})
+		if client.IgnoreNotFound(err) != nil {
+			return errors.Wrap(err, "failed deleting last known good configMap")
+		}
+
+		*digest = newDigest
+		return nil
+	}
+
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name.Name,
+			Namespace:   name.Namespace,
+			Annotations: map[string]string{lastKnownGoodOf.String(): util.ObjectResourceName(mt)},
+		},
+		Data: data,
+	}
+
+	if err := r.Patch(ctx, cm, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
+		return errors.Wrap(err, "failed saving last known good configMap")
+	}
+
+	*digest = newDigest
+	return nil
+}
+
+// deleteLastKnownGood deletes the last known good ConfigMap of the MergeTarget.
+func (r *MergeTargetReconciler) deleteLastKnownGood(ctx context.Context, mt *MergeTarget) error {
+	name := lastKnownGoodConfigMapName(mt)
+	err := r.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace}})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	return errors.Wrap(err, "failed deleting last known good configMap")
+}
+
+// lastKnownGoodDigest is the digest of the data of a last known good ConfigMap.
+func lastKnownGoodDigest(data map[string]string) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return util.Digest(string(encoded)), nil
+}
@@ -18,17 +18,18 @@ package controllers
 
 import (
 	"context"
-	"time"
+	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
@@ -44,71 +45,42 @@ type MergeSourceReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder *metrics.Recorder
+
+	// DigestOnly omits the accumulated output from the MergeSource status,
+	// keeping only its digest and the number of sources.
+	DigestOnly bool
 }
 
 //+kubebuilder:rbac:groups=config.cmmc.k8s.cash.app,resources=mergesources,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=config.cmmc.k8s.cash.app,resources=mergesources/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=config.cmmc.k8s.cash.app,resources=mergesources/finalizers,verbs=update
-//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 func (r *MergeSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	log := log.FromContext(ctx)
-
-	// check to see if we're getting a configMap from our watcher
-	watched := r.watchedConfigMap(ctx, req.NamespacedName)
-	if watched == nil {
-		// received ConfigMap that isn't watched / annotated, so we are skipping
-		// work here, nothing else to do, nothing to resubmit.
-		log.Info("irrelevant configmap, skipping reconcile")
-		return ctrl.Result{}, nil
-	}
-
-	mergeSource, err := r.mergeSource(ctx, watched)
-	if err != nil {
-		log.Error(err, "failed to fetch merge source")
-		return ctrl.Result{Requeue: true}, err
-	}
-
-	stop, err := r.reconcileMergeSource(ctx, mergeSource, watched)
-	if err != nil {
-		return ctrl.Result{Requeue: true}, errors.Wrap(err, "could not reconcile MergeSource")
-	} else if stop {
-		return ctrl.Result{}, nil
+	var mergeSource cmmcv1beta1.MergeSource
+	if err := r.Get(ctx, req.NamespacedName, &mergeSource); err != nil {
+		return ctrl.Result{}, errors.WithStack(client.IgnoreNotFound(err))
 	}
 
-	if err := r.maybeRemoveWatchedAnnotation(ctx, watched); err != nil {
-		return ctrl.Result{Requeue: true}, err
+	if err := r.reconcileMergeSource(ctx, &mergeSource); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "could not reconcile MergeSource")
 	}
 
-	// means we were coming from a ConfigMap
-	if watched.WatchedBy != req.NamespacedName {
-		return ctrl.Result{}, nil
-	}
-
-	// Actually were reconciling & managing our MergeSource,
-	// we are going to check and see if there are going to be any
-	// other sources existing...
-	return ctrl.Result{RequeueAfter: time.Minute}, nil
+	// Any changes to the sources will trigger a new reconciliation.
+	return ctrl.Result{}, nil
 }
 
-//nolint:funlen
-func (r *MergeSourceReconciler) reconcileMergeSource(
-	ctx context.Context, mergeSource *MergeSource, watched *watchedConfigMap,
-) (bool, error) {
-	if mergeSource == nil {
-		return false, nil
-	}
-
+func (r *MergeSourceReconciler) reconcileMergeSource(ctx context.Context, mergeSource *MergeSource) error {
 	log := log.FromContext(ctx)
 
 	isDeleting, err := finalizer.
 		New(
 			mergeSourceFinalizerName,
 			func() error {
-				return r.finalizeDeletion(ctx, mergeSource, watched)
+				return r.finalizeDeletion(ctx, mergeSource)
 			},
 			func() error {
 				r.Recorder.RecordNumSources(mergeSource, 0)
@@ -118,244 +90,206 @@ func (r *MergeSourceReconciler) reconcileMergeSource(
 		).
 		Execute(ctx, r.Client, mergeSource)
 	if err != nil {
-		return false, errors.WithStack(err)
+		return errors.WithStack(err)
 	} else if isDeleting {
-		return true, nil
+		return nil
 	}
 
 	defer r.Recorder.RecordReadyCondition(mergeSource)
 
-	sources, err := r.sources(ctx, mergeSource)
+	if mergeSource.Spec.Suspend {
+		log.Info("suspended, not reconciling")
+		base := mergeSource.DeepCopy()
+		mergeSource.SetStatusCondition(cmmcv1beta1.MergeSourceConditionSuspended())
+		return errors.Wrap(patchStatus(
+			ctx, r.Client, base, mergeSource,
+			func(o *MergeSource) interface{} { return o.Status },
+			func(from, to *MergeSource) { to.Status = *from.Status.DeepCopy() },
+		), "failed updating status of suspended MergeSource")
+	}
+
+	sources, err := listSources(ctx, r.Client, mergeSource)
 	if err != nil {
-		return false, errors.WithStack(client.IgnoreNotFound(err))
+		return errors.WithStack(client.IgnoreNotFound(err))
 	}
 
 	r.Recorder.RecordNumSources(mergeSource, len(sources))
 	log = log.WithValues("numSources", len(sources))
 
-	var output string
-	for _, cm := range sources {
-		data, err := r.configMapOutput(ctx, cm, mergeSource.Spec.Source.Data, watched)
-		if err != nil {
-			return false, errors.Wrap(err, "failed accumulating source")
-		}
-		output += data
+	if err := r.syncWatchedByAnnotations(ctx, mergeSource, sources); err != nil {
+		return errors.Wrap(err, "failed updating watchedBy annotations")
 	}
 
-	// Retrieve new copy of the current MergeSource so that we're updating the most recent
-	// version of the resource when we update its status.
-	ms := &cmmcv1beta1.MergeSource{}
-	err = r.Get(ctx, types.NamespacedName{
-		Namespace: mergeSource.Namespace,
-		Name:      mergeSource.Name,
-	}, ms)
-	if err != nil {
-		return false, errors.Wrapf(err, "error retrieving mergeSource %s during status update phase", mergeSource.Name)
+	// dry-run sources aren't part of the output, but changing them changes the
+	// status (and so gets their MergeTarget to validate them again).
+	var output, dryRun string
+	for _, cm := range sources {
+		cm := cm
+		data := cm.Data[mergeSource.Spec.Source.Data]
+		if isDryRunSource(&cm) {
+			dryRun += fmt.Sprintf("%s\n%s\n", util.ObjectResourceName(&cm), util.Digest(data))
+		} else {
+			output += data
+		}
 	}
 
-	// Use the newly retrieved MergeSource to update the status.
-	ms.Status.Output = output
-	ms.SetStatusCondition(cmmcv1beta1.MergeSourceConditionReady(len(sources)))
-	if err = r.Status().Update(ctx, ms); err != nil {
-		return false, errors.Wrap(err, "failed updating status after accumulating watched resources")
+	outputDigest := util.Digest(output)
+	if r.DigestOnly {
+		output = ""
 	}
 
-	log.Info("updated status")
-	return false, nil
-}
-
-func (r *MergeSourceReconciler) finalizeDeletion(
-	ctx context.Context, s *MergeSource, w *watchedConfigMap,
-) error {
-	sources, err := r.sources(ctx, s)
-	if err != nil {
-		return err
+	var dryRunDigest string
+	if dryRun != "" {
+		dryRunDigest = util.Digest(dryRun)
 	}
 
-	for _, cm := range sources {
-		cm := cm
-		if err := r.cleanUpWatchedByAnnotation(ctx, &cm, w.WatchedBy.String()); err != nil {
-			return err
-		}
+	// Only write the status if anything actually changed.
+	base := mergeSource.DeepCopy()
+	mergeSource.Status.Output = output
+	mergeSource.Status.OutputDigest = outputDigest
+	mergeSource.Status.DryRunDigest = dryRunDigest
+	mergeSource.Status.NumSources = len(sources)
+	mergeSource.SetStatusCondition(cmmcv1beta1.MergeSourceConditionReady(len(sources)))
+	mergeSource.RemoveStatusCondition(cmmcv1beta1.MergeSourceConditionTypeSuspended)
+	if err := patchStatus(
+		ctx, r.Client, base, mergeSource,
+		func(o *MergeSource) interface{} { return o.Status },
+		func(from, to *MergeSource) { to.Status = *from.Status.DeepCopy() },
+	); err != nil {
+		return errors.Wrap(err, "failed updating status after accumulating watched resources")
 	}
 
-	r.Recorder.RecordNumSources(s, 0)
-
+	log.V(1).Info("reconciled")
 	return nil
 }
 
-func (r *MergeSourceReconciler) mergeSource(
-	ctx context.Context, w *watchedConfigMap,
-) (*MergeSource, error) {
-	var s cmmcv1beta1.MergeSource
-	err := r.Get(ctx, w.WatchedBy, &s)
-	if err == nil {
-		return &s, nil
+func (r *MergeSourceReconciler) finalizeDeletion(ctx context.Context, s *MergeSource) error {
+	if s.Spec.DeletionPolicy == cmmcv1beta1.DeletionPolicyRetain {
+		log.FromContext(ctx).Info("retaining watchedBy annotations")
+	} else if err := r.syncWatchedByAnnotations(ctx, s, nil); err != nil {
+		return err
 	}
 
-	if !apierrors.IsNotFound(err) {
-		return nil, errors.WithStack(err)
-	}
+	r.Recorder.RecordNumSources(s, 0)
 
-	return nil, nil //nolint:nilnil
+	return nil
 }
 
-func (r *MergeSourceReconciler) sources(
-	ctx context.Context, s *cmmcv1beta1.MergeSource,
-) ([]corev1.ConfigMap, error) {
-	var sources corev1.ConfigMapList
-	if err := r.List(ctx, &sources, client.MatchingLabels(s.Spec.Selector)); err != nil {
-		return nil, errors.WithStack(err)
-	}
+// syncWatchedByAnnotations makes sure exactly the given sources are annotated
+// as being watched by the MergeSource (if it opted in to annotating them at all).
+//
+// ConfigMaps are only written when the annotation needs to change.
+func (r *MergeSourceReconciler) syncWatchedByAnnotations(
+	ctx context.Context, s *MergeSource, sources []corev1.ConfigMap,
+) error {
+	var (
+		name     = util.ObjectResourceName(s)
+		expected = map[types.NamespacedName]struct{}{}
+	)
 
-	if len(sources.Items) == 0 {
-		return nil, nil
-	}
+	if s.Spec.AnnotateSources && s.GetDeletionTimestamp().IsZero() {
+		for _, cm := range sources {
+			cm := cm
+			expected[util.ObjectNamespacedName(&cm)] = struct{}{}
 
-	namespaceSelector := s.NamespaceSelector()
-	if len(namespaceSelector) == 0 {
-		return sources.Items, nil
-	}
+			if watchedBy.Contains(&cm, name) {
+				continue
+			}
 
-	var nsList corev1.NamespaceList
-	if err := r.List(ctx, &nsList, client.MatchingLabels(namespaceSelector)); err != nil {
-		return nil, errors.WithStack(err)
+			if err := anns.Patch(ctx, r.Client, &cm, fieldManager, watchedBy.AddToList(name)); err != nil {
+				return errors.Wrapf(err, "error annotating configMap %s", util.ObjectResourceName(&cm))
+			}
+		}
 	}
 
-	if len(nsList.Items) == 0 {
-		log.FromContext(ctx).Info("[WARN] found no matching namespaces, filtering all configMaps", "selector", namespaceSelector)
-		return nil, nil
+	var annotated corev1.ConfigMapList
+	if err := r.List(ctx, &annotated, client.MatchingFields{fieldIndexWatchedBy: name}); err != nil {
+		return errors.WithStack(err)
 	}
 
-	nsMap := map[string]struct{}{}
-	for _, ns := range nsList.Items {
-		nsMap[ns.GetName()] = struct{}{}
-	}
+	for _, cm := range annotated.Items {
+		cm := cm
+		if _, ok := expected[util.ObjectNamespacedName(&cm)]; ok {
+			continue
+		}
 
-	n := 0
-	for _, cm := range sources.Items {
-		_, ok := nsMap[cm.GetNamespace()]
-		if ok {
-			sources.Items[n] = cm
-			n++
+		log.FromContext(ctx).Info("removing annotation", "config-map", util.ObjectResourceName(&cm))
+		if err := anns.Patch(ctx, r.Client, &cm, fieldManager, watchedBy.RemoveFromList(name)); err != nil {
+			return errors.Wrapf(err, "error removing annotation from configMap %s", util.ObjectResourceName(&cm))
 		}
 	}
 
-	return sources.Items[:n], nil
+	return nil
 }
 
-func (r *MergeSourceReconciler) configMapOutput(
-	ctx context.Context, cm corev1.ConfigMap,
-	key string,
-	w *watchedConfigMap,
-) (string, error) {
-	if w.Name == util.ObjectResourceName(&cm) {
-		w.ShouldBeRemoved = false
-	}
+const (
+	fieldIndexWatchedBy = "metadata.annotations.watchedBy"
+)
 
-	// ensure selector matched configMap has the watchedByAnnotation
-	if err := r.annotateWatchedByConfigMap(ctx, &cm, w.WatchedBy.String()); err != nil {
-		return "", errors.Wrap(err, "error updating watchedBy annotation on configMap")
+func watchedByIndexer(o client.Object) []string {
+	var names []string
+	for _, n := range watchedBy.ParseObjectNames(o) {
+		names = append(names, n.String())
 	}
 
-	data := cm.Data[key]
-	return data, nil
+	return names
 }
 
-// Delete the annotation if there is no merge source watching the configmap
-// Otherwise, remove the mergesource from the annotations.
-func (r *MergeSourceReconciler) cleanUpWatchedByAnnotation(
-	ctx context.Context, cm *corev1.ConfigMap, name string,
-) error {
-	return errors.WithStack(anns.Apply(ctx, r.Client, cm, watchedBy.RemoveFromList(name)))
-}
+// mergeSourcesForConfigMap maps a ConfigMap to the MergeSources that are
+// selecting it, or have annotated it.
+//
+// On updates this gets called with both the old and the new ConfigMap, so a
+// ConfigMap that stopped matching a selector is still mapped to the MergeSource.
+func (r *MergeSourceReconciler) mergeSourcesForConfigMap(o client.Object) []reconcile.Request {
+	var (
+		ctx  = context.Background()
+		reqs []reconcile.Request
+		seen = map[types.NamespacedName]struct{}{}
+	)
 
-func (r *MergeSourceReconciler) annotateWatchedByConfigMap(
-	ctx context.Context, cm *corev1.ConfigMap, name string,
-) error {
-	// Retrieve the latest copy of the configmap we're looking to update
-	// before triggering the update to prevent errors with updating a resource
-	// without the most recent changes
-	currentCm := &corev1.ConfigMap{}
-	err := r.Get(ctx, types.NamespacedName{
-		Namespace: cm.Namespace,
-		Name:      cm.Name,
-	}, currentCm)
-	if err != nil {
-		// If not found, the ConfigMap has been deleted
-		if apierrors.IsNotFound(err) {
-			return nil
+	add := func(n types.NamespacedName) {
+		if _, ok := seen[n]; !ok {
+			seen[n] = struct{}{}
+			reqs = append(reqs, reconcile.Request{NamespacedName: n})
 		}
-		return errors.Wrapf(err, "error retrieving configmap %s for watched annotation update", cm.Name)
 	}
 
-	return errors.WithStack(anns.Apply(ctx, r.Client, currentCm, watchedBy.AddToList(name)))
-}
-
-type watchedConfigMap struct {
-	Resource        corev1.ConfigMap
-	Name            string
-	ShouldBeRemoved bool
-	WatchedBy       types.NamespacedName
-}
-
-func (r *MergeSourceReconciler) watchedConfigMap(
-	ctx context.Context, name types.NamespacedName,
-) *watchedConfigMap {
-	var cm corev1.ConfigMap
-	if err := r.Get(ctx, name, &cm); err != nil {
-		// If we didn't find a configMap, we have nothing to remove, and we
-		// can keep going to check on the name being a MergeSource.
-		return &watchedConfigMap{WatchedBy: name}
+	for _, n := range watchedBy.ParseObjectNames(o) {
+		add(n)
 	}
 
-	// if it's not being watched by anything, we can abort here
-	// since we found an irrelevant configMap
-	n, ok := watchedBy.ParseObjectName(&cm)
-	if !ok {
-		return nil
+	var mergeSources cmmcv1beta1.MergeSourceList
+	if err := r.List(ctx, &mergeSources); err != nil {
+		log.FromContext(ctx).Error(err, "failed listing MergeSources for ConfigMap", "config-map", util.ObjectResourceName(o))
+		return reqs
 	}
 
-	return &watchedConfigMap{
-		ShouldBeRemoved: true, // assume we should be removing it unless proven otherwise
-		Resource:        cm,
-		Name:            util.ObjectResourceName(&cm),
-		WatchedBy:       n,
-	}
-}
-
-func (r *MergeSourceReconciler) maybeRemoveWatchedAnnotation(
-	ctx context.Context, w *watchedConfigMap,
-) error {
-	if !w.ShouldBeRemoved {
-		return nil
-	}
-
-	log.FromContext(ctx).Info("attempting to remove annotation", "config-map", w.Name)
-	err := r.cleanUpWatchedByAnnotation(ctx, &w.Resource, w.WatchedBy.String())
-	if err != nil {
-		return err
+	for _, s := range mergeSources.Items {
+		s := s
+		if s.MatchesConfigMap(o) {
+			add(util.ObjectNamespacedName(&s))
+		}
 	}
 
-	log.FromContext(ctx).Info("cleaned up annotation")
-	return nil
+	return reqs
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *MergeSourceReconciler) SetupWithManager(mgr ctrl.Manager, opts controller.Options) error {
+	ctx := context.Background()
+	if err := mgr.GetFieldIndexer().IndexField(
+		ctx, &corev1.ConfigMap{}, fieldIndexWatchedBy, watchedByIndexer,
+	); err != nil {
+		return errors.Wrapf(err, "error setting field indexer for field = %s", fieldIndexWatchedBy)
+	}
+
 	return errors.WithStack(
 		ctrl.NewControllerManagedBy(mgr).
 			For(&cmmcv1beta1.MergeSource{}).
 			WithOptions(opts).
 			Watches(
 				&source.Kind{Type: &corev1.ConfigMap{}},
-				watchReconciliationEventHandler(
-					watchedBy.ParseObjectName,
-					func(o client.Object) (types.NamespacedName, bool) {
-						n := util.ObjectNamespacedName(o)
-						return n, true
-					},
-				),
+				handler.EnqueueRequestsFromMapFunc(r.mergeSourcesForConfigMap),
 			).Complete(r),
 	)
 }
@@ -19,23 +19,29 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 
 	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
-	anns "github.com/cashapp/cmmc/util/annotations"
+	"github.com/cashapp/cmmc/util"
+	"github.com/cashapp/cmmc/util/diff"
 	"github.com/cashapp/cmmc/util/finalizer"
 	"github.com/cashapp/cmmc/util/metrics"
 	corev1 "k8s.io/api/core/v1"
@@ -46,6 +52,20 @@ type MergeTargetReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder *metrics.Recorder
+
+	// APIReader (if set) reads directly from the API server, for reads that
+	// must not be stale, e.g. to make sure a target ConfigMap is really gone.
+	APIReader client.Reader
+
+	// UncachedTargets reads target ConfigMaps with the APIReader instead of
+	// the cache, for when the cache only holds a subset of the ConfigMaps.
+	UncachedTargets bool
+
+	// ResyncPeriod (if set) is how often MergeTargets are reconciled, even if
+	// nothing they watch changed, for when target ConfigMaps aren't watched.
+	ResyncPeriod time.Duration
+
+	EventRecorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=config.cmmc.k8s.cash.app,resources=mergetargets,verbs=get;list;watch;create;update;patch;delete
@@ -53,11 +73,17 @@ type MergeTargetReconciler struct {
 //+kubebuilder:rbac:groups=config.cmmc.k8s.cash.app,resources=mergetargets/finalizers,verbs=update
 //+kubebuilder:rbac:groups=config.cmmc.k8s.cash.app,resources=mergesources,verbs=get;list;watch
 //+kubebuilder:rbac:groups=config.cmmc.k8s.cash.app,resources=mergesources/status,verbs=get;list
-//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;update;create;delete
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;update;patch;create;delete
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-func (r *MergeTargetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+//
+// The status of the MergeTarget is computed in memory while reconciling, and
+// written with (at most) a single patch at the end.
+func (r *MergeTargetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	var (
 		mtName = req.NamespacedName.String()
 		log    = log.FromContext(ctx)
@@ -65,21 +91,63 @@ func (r *MergeTargetReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	// 1. We fetch the MergeTarget.
 	var mergeTarget cmmcv1beta1.MergeTarget
-	err := r.Get(ctx, req.NamespacedName, &mergeTarget)
-	if err != nil {
+	if err := r.Get(ctx, req.NamespacedName, &mergeTarget); err != nil {
 		return ctrl.Result{}, errors.WithStack(client.IgnoreNotFound(err))
 	}
 
-	setStatusCondition := func(c metav1.Condition) error {
-		return errors.WithStack(r.setStatusCondition(ctx, &mergeTarget, c))
+	base := mergeTarget.DeepCopy()
+	stateDigest, err := r.restoreState(ctx, &mergeTarget)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	lastKnownGood, lastKnownGoodDigest, err := r.loadLastKnownGood(ctx, &mergeTarget)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// saveState backs up the revert state, and saves the last valid
+	// contributions, this has to happen before the target ConfigMap or the
+	// status are written.
+	saveState := func() error {
+		if !mergeTarget.GetDeletionTimestamp().IsZero() {
+			return nil
+		}
+
+		if err := r.saveState(ctx, &mergeTarget, &stateDigest); err != nil {
+			return err
+		}
+
+		return r.saveLastKnownGood(ctx, &mergeTarget, lastKnownGood, &lastKnownGoodDigest)
 	}
 
+	defer func() {
+		if saveErr := saveState(); saveErr != nil {
+			// never let the status get ahead of the backed up state.
+			if err == nil {
+				err = saveErr
+			}
+			return
+		}
+
+		if patchErr := r.patchStatus(ctx, base, &mergeTarget); patchErr != nil && err == nil {
+			err = patchErr
+		}
+	}()
+
+	// changes to target ConfigMaps that aren't watched are only seen when resyncing.
+	defer func() {
+		if err == nil && result.IsZero() && mergeTarget.GetDeletionTimestamp().IsZero() {
+			result.RequeueAfter = r.ResyncPeriod
+		}
+	}()
+
 	// 2. Validate the spec.target name, (the ConfigMap), and update
 	//    the status accordingly if it's bad.
 	targetName, err := mergeTarget.NamespacedTargetName()
 	if err != nil {
-		_ = setStatusCondition(cmmcv1beta1.MergeTargetConditionMissingTarget(err))
-		return ctrl.Result{RequeueAfter: time.Minute}, errors.WithStack(err)
+		mergeTarget.SetStatusCondition(cmmcv1beta1.MergeTargetConditionMissingTarget(err))
+		return ctrl.Result{}, errors.WithStack(err)
 	}
 
 	// 3. Ensure finalizer / cleanup is running correctly.
@@ -87,81 +155,280 @@ func (r *MergeTargetReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		New(
 			mergeTargetFinalizerName,
 			func() error {
-				return r.finalizeDeletion(ctx, targetName, &mergeTarget)
+				if err := r.finalizeDeletion(
+					ctx, mtName, managedTargetName(&mergeTarget, targetName), &mergeTarget,
+				); err != nil {
+					return err
+				}
+
+				if err := r.reportContributions(ctx, mtName, &mergeTarget, nil, nil); err != nil {
+					return err
+				} else if err := r.deleteLastKnownGood(ctx, &mergeTarget); err != nil {
+					return err
+				}
+
+				return r.deleteState(ctx, &mergeTarget)
 			},
 			func() error {
 				r.Recorder.RecordNumSources(&mergeTarget, 0)
 				r.Recorder.RecordCondition(&mergeTarget, metav1.Condition{Type: "Ready"})
+				r.Recorder.ForgetSchemaViolations(&mergeTarget)
 				return nil
 			},
 		).
 		Execute(ctx, r.Client, &mergeTarget)
 	if err != nil {
-		return ctrl.Result{Requeue: true}, errors.WithStack(err)
+		return ctrl.Result{}, errors.WithStack(err)
 	} else if isDeleting {
 		return ctrl.Result{}, nil
 	}
 
 	defer r.Recorder.RecordReadyCondition(&mergeTarget)
 
-	// 4. Find/setup the target ConfigMap
-	targetConfigMap, requeue, err := r.targetConfigMap(ctx, &mergeTarget, targetName, mtName)
+	// 4. Leave everything as it is while suspended.
+	if mergeTarget.Spec.Suspend {
+		log.Info("suspended, not reconciling")
+		mergeTarget.SetStatusCondition(cmmcv1beta1.MergeTargetConditionSuspended())
+		return ctrl.Result{}, nil
+	}
+
+	mergeTarget.RemoveStatusCondition(cmmcv1beta1.MergeTargetConditionTypeSuspended)
+
+	// 5. Only compute what would be written in dry-run mode.
+	if mergeTarget.Spec.DryRun {
+		return ctrl.Result{}, r.dryRun(ctx, mtName, &mergeTarget, targetName, lastKnownGood)
+	} else if err := r.endDryRun(ctx, &mergeTarget); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// 6. Move away from the previous target ConfigMap, if spec.target changed.
+	if err := r.moveTarget(ctx, mtName, &mergeTarget, targetName); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// 7. Find/setup the target ConfigMap
+	targetConfigMap, requeue, err := r.targetConfigMap(ctx, &mergeTarget, targetName, saveState)
 	if err != nil {
 		log.Info("error fetching target config-map")
 		return ctrl.Result{Requeue: requeue}, err
+	} else if requeue {
+		return ctrl.Result{Requeue: true}, nil
+	} else if targetConfigMap == nil {
+		return ctrl.Result{}, nil
+	}
+
+	// 8. Hand keys over to another MergeTarget, if asked to.
+	if err := r.handOver(ctx, mtName, &mergeTarget, targetConfigMap, saveState); err != nil {
+		return ctrl.Result{}, err
 	}
 
-	// 5. Do actual recondiliation.
-	result, err := r.reconcileMergeTarget(ctx, mtName, &mergeTarget, targetConfigMap)
-	return result, errors.WithStack(err)
+	// 9. Do actual recondiliation.
+	return ctrl.Result{}, errors.WithStack(
+		r.reconcileMergeTarget(ctx, mtName, &mergeTarget, targetConfigMap, lastKnownGood, saveState),
+	)
 }
 
 // reconcileMergeTarget is the main function that ensures the target ConfigMap
 // has the state it needs to have given the MergeTarget resource.
+//
+// If none of its inputs changed since the last successful reconciliation it does nothing.
 func (r *MergeTargetReconciler) reconcileMergeTarget(
 	ctx context.Context, mtName string, mt *MergeTarget, cm *corev1.ConfigMap,
-) (ctrl.Result, error) {
-	var (
-		log                = log.FromContext(ctx)
-		setStatusCondition = func(c metav1.Condition) error {
-			return errors.WithStack(r.setStatusCondition(ctx, mt, c))
+	lastKnownGood cmmcv1beta1.LastKnownGood, saveState func() error,
+) error {
+	log := log.FromContext(ctx)
+
+	contributions, suspended, numMergeSources, err := r.contributions(ctx, mtName)
+	if err != nil {
+		return err
+	}
+
+	contributions = mt.FreezeContributions(contributions, suspended)
+
+	r.Recorder.RecordNumSources(mt, numMergeSources)
+	if err := acceptHandover(ctx, r.Client, mtName, mt, cm); err != nil {
+		return errors.Wrap(err, "failed accepting handover")
+	}
+
+	inits, err := r.initFromValues(ctx, mt)
+	if err != nil {
+		// creating or updating the ConfigMap will trigger a new reconcile.
+		mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionInitFromMissing(err))
+		log.Info("failed loading initial values", "error", err.Error())
+		return nil
+	}
+
+	if mt.Status.InputDigest == inputDigest(mt, cm, contributions, inits) {
+		log.V(1).Info("inputs unchanged, skipping")
+		return nil
+	}
+
+	if token, ok := pendingRequest(mt, reconcileRequest); ok {
+		log.Info("reconcile requested", "requestedAt", token)
+		markRequestHandled(mt, reconcileRequest, token)
+	}
+
+	// make sure no other MergeTarget manages the keys of this one.
+	if err := claimTarget(ctx, r.Client, cm, mtName, claimedKeys(mt)); err != nil {
+		var conflict *keyConflictError
+		if errors.As(err, &conflict) {
+			if from, ok := handoverFrom.ParseObjectName(mt); ok && from.String() == conflict.Owner {
+				mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionAwaitingHandover(conflict.Key, conflict.Owner))
+			} else {
+				mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionKeyConflict(conflict.Key, conflict.Owner))
+			}
+			mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionConflicting(conflict))
+			log.Info("target configMap key managed by another MergeTarget", "key", conflict.Key, "owner", conflict.Owner)
+			return nil
 		}
-	)
 
-	if err := r.updateDataStatus(ctx, mt, cm); err != nil {
-		return ctrl.Result{Requeue: true}, errors.Wrap(err, "failed updating MergeTarget Status")
+		return errors.Wrap(err, "failed claiming target configMap keys")
 	}
 
-	log.Info("initialized/updated initial state of the target")
+	mt.UpdateDataStatus(cm.Data)
+	mt.SetInits(inits)
+	r.rebaseline(ctx, mt, cm, contributions, lastKnownGood)
+
+	drifted := detectDrift(mtName, mt, cm)
+	r.reportDrift(mt, drifted)
+	if len(drifted) > 0 && mt.Spec.DriftPolicy == cmmcv1beta1.DriftPolicyPreserve {
+		token, ok := pendingRequest(mt, acknowledgeDrift)
+		if !ok {
+			// acknowledging the drift changes the inputs, and gets us back here.
+			log.Info("preserving modified keys, not writing target configMap", "keys", drifted.Keys())
+			return nil
+		}
+
+		log.Info("drift acknowledged, reverting modified keys", "keys", drifted.Keys())
+		markRequestHandled(mt, acknowledgeDrift, token)
+	}
+
+	live := make(map[string]string, len(cm.Data))
+	for k, v := range cm.Data {
+		live[k] = v
+	}
+
+	// values are parsed once, for the merge, the validation of the data and
+	// the outcomes of the contributions.
+	docs := cmmcv1beta1.Documents{}
 
 	// N.B. We don't initially remove the keys to make sure the udpate
 	// goes through successfully before we cleanup the status.
-	keysToRemove, stats, err := r.reduceTargetState(ctx, mtName, mt, cm)
-	if err != nil {
-		return ctrl.Result{RequeueAfter: time.Minute}, errors.WithStack(err)
+	keysToRemove, numUpdatedKeys, fieldsErrorMsgs, schemaWarnings := mt.ReduceDataState(docs, contributions, lastKnownGood, &cm.Data)
+
+	reduced := make(map[string]string, len(cm.Data))
+	for k, v := range cm.Data {
+		reduced[k] = v
+	}
+
+	// the contributions to the keys that end up not being updated are blocked,
+	// for the reason given by key.
+	var (
+		held     = map[string]string{}
+		heldBack = "the merged value of the key isn't written"
+	)
+
+	// drifted keys that aren't reverted keep their current values.
+	var keptKeys []string
+	if mt.Spec.DriftPolicy == cmmcv1beta1.DriftPolicyAlertOnly {
+		keptKeys = keptDrift(mt, drifted, cm.Data)
+	}
+	numUpdatedKeys -= keepValues(keptKeys, live, cm.Data)
+	holdKeys(held, keptKeys, "the key was modified by someone else, and the modification is kept")
+
+	if mt.Spec.RollbackTo != nil {
+		if numUpdatedKeys, err = r.rollBack(ctx, mt, live, cm.Data); err != nil {
+			// changing spec.rollbackTo gets us back here.
+			mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionRollbackFailed(*mt.Spec.RollbackTo, err))
+			log.Info("failed rolling back", "revision", *mt.Spec.RollbackTo, "error", err.Error())
+			return nil
+		}
+
+		mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionRolledBack(*mt.Spec.RollbackTo))
+		heldBack = fmt.Sprintf("the target is rolled back to revision %d", *mt.Spec.RollbackTo)
+	} else {
+		mt.RemoveStatusCondition(cmmcv1beta1.MergeTargetConditionTypeRollback)
+		blocked := r.guardRemovals(ctx, mt, live, cm.Data, false)
+		numUpdatedKeys -= len(blocked)
+		holdKeys(held, blocked, "too many entries of the key would be removed at once")
+	}
+
+	refused := r.refuseMissingRequired(ctx, mt, live, cm.Data)
+	numUpdatedKeys -= len(refused)
+	holdKeys(held, refused, "the merged value of the key is missing required entries")
+	holdKeys(held, diff.ChangedKeys(reduced, cm.Data), heldBack)
+	cmmcv1beta1.BlockContributions(contributions, held)
+
+	stats := &mergeStats{
+		NumUpdatedKeys:  numUpdatedKeys,
+		NumMergeSources: numMergeSources,
+		FieldsErrorMsgs: fieldsErrorMsgs,
 	}
 
 	// record the status/condition of the things we are going to attempt to store.
-	r.Recorder.RecordNumSources(mt, stats.NumMergeSources)
 	stats.LogWithValues(log).Info("found and merged sources")
-	err = setStatusCondition(cmmcv1beta1.MergeTargetConditionValidation(stats.FieldsErrorMsgs, stats.NumMergeSources))
-	if err != nil {
-		return ctrl.Result{Requeue: true}, errors.WithStack(err)
+	mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionValidation(stats.FieldsErrorMsgs, stats.NumMergeSources))
+	r.reportSchemaWarnings(mt, schemaWarnings)
+
+	// the data is validated as a whole last, and isn't written at all if it
+	// doesn't validate. Changing the sources or the spec gets us back here.
+	if !r.reportSchemaViolation(mt, mt.ValidateData(docs, cm.Data)) {
+		log.Info("target configMap data doesn't validate against the schema, not writing it")
+
+		invalid := make([]string, 0, len(mt.Spec.Data))
+		for k := range mt.Spec.Data {
+			invalid = append(invalid, k)
+		}
+
+		holdKeys(held, invalid, "the data of the target doesn't validate against its schema")
+		cmmcv1beta1.BlockContributions(contributions, held)
+		return r.reportContributions(ctx, mtName, mt, docs, contributions)
+	}
+
+	// if we should be doing an update, let's do it
+	if stats.NumUpdatedKeys > 0 || len(keysToRemove) > 0 || !isApplied(cm, mtName) {
+		if err := saveState(); err != nil {
+			return err
+		}
+
+		if err := r.writeTarget(ctx, mtName, mt, cm, keysToRemove, keptKeys); err != nil {
+			mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionErrorUpdating(err, stats.NumUpdatedKeys))
+
+			// conflicts won't resolve themselves by retrying, changes to the
+			// target configMap will trigger a new reconcile.
+			var conflict *fieldConflictError
+			if errors.As(err, &conflict) {
+				mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionFieldManagerConflict(conflict.Owners, conflict.Error()))
+				log.Info("conflicting field managers on target configMap", "owners", conflict.Owners, "conflicts", conflict.Error())
+				return nil
+			}
+
+			return errors.Wrap(err, "failed updating target configMap")
+		}
 	}
 
-	if stats.NumUpdatedKeys > 0 { // if we should be doing an update, let's do it
-		if err := r.Update(ctx, cm); err != nil {
-			_ = setStatusCondition(cmmcv1beta1.MergeTargetConditionErrorUpdating(err, stats.NumUpdatedKeys))
-			return ctrl.Result{RequeueAfter: time.Minute}, errors.Wrap(err, "failed updating target configMap")
+	mt.RemoveStatusCondition(cmmcv1beta1.MergeTargetConditionTypeConflict)
+	mt.RecordApplied(cm.Data, keptKeys)
+
+	if mt.Spec.RollbackTo != nil {
+		if stats.NumUpdatedKeys > 0 {
+			r.EventRecorder.Eventf(mt, corev1.EventTypeNormal, "RolledBack",
+				"Rolled back the target to revision %d", *mt.Spec.RollbackTo)
 		}
+	} else if err := r.recordRevision(ctx, mt, cm, contributions); err != nil {
+		return err
+	}
+
+	if err := r.reportContributions(ctx, mtName, mt, docs, contributions); err != nil {
+		return err
 	}
 
 	// do Status cleanup, and set the right condition
 	mt.RemoveDataStatusKeys(keysToRemove)
 	mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionReady(len(stats.FieldsErrorMsgs) > 0))
-	err = r.Status().Update(ctx, mt)
+	mt.Status.InputDigest = inputDigest(mt, cm, contributions, inits)
 
-	return ctrl.Result{RequeueAfter: time.Minute}, errors.WithStack(err)
+	return nil
 }
 
 type mergeStats struct {
@@ -178,111 +445,211 @@ func (m *mergeStats) LogWithValues(l logr.Logger) logr.Logger {
 	)
 }
 
-func (r *MergeTargetReconciler) updateDataStatus(
-	ctx context.Context, mt *MergeTarget, cm *corev1.ConfigMap,
-) error {
-	mt.UpdateDataStatus(cm.Data)
-	return errors.Wrap(r.Status().Update(ctx, mt), "failed updating initial status")
-}
-
-func (r *MergeTargetReconciler) setStatusCondition(
-	ctx context.Context, mt *MergeTarget, c metav1.Condition,
-) error {
-	mt.SetStatusCondition(c)
-	return errors.Wrapf(r.Status().Update(ctx, mt), "error updating condition type %s", c.Type)
+func (r *MergeTargetReconciler) patchStatus(ctx context.Context, base, mt *MergeTarget) error {
+	return patchStatus(
+		ctx, r.Client, base, mt,
+		func(o *MergeTarget) interface{} { return o.Status },
+		func(from, to *MergeTarget) { to.Status = *from.Status.DeepCopy() },
+	)
 }
 
-func (r *MergeTargetReconciler) reduceTargetState(
-	ctx context.Context, name string, mt *MergeTarget, targetConfigMap *corev1.ConfigMap,
-) ([]string, *mergeStats, error) {
+// contributions lists the contributions of all MergeSources targeting the
+// MergeTarget, and the suspended MergeSources.
+func (r *MergeTargetReconciler) contributions(
+	ctx context.Context, name string,
+) ([]cmmcv1beta1.Contribution, []types.NamespacedName, int, error) {
 	var mergeSources cmmcv1beta1.MergeSourceList
 	if err := r.List(ctx, &mergeSources, client.MatchingFields{fieldIndexStatusTarget: name}); err != nil {
-		return nil, nil, errors.Wrapf(err, "failed fetching MergeSource list for %s", name)
+		return nil, nil, 0, errors.Wrapf(err, "failed fetching MergeSource list for %s", name)
 	}
 
-	statusKeysToRemove, numUpdatedKeys, errorsOnFields := mt.ReduceDataState(mergeSources, &targetConfigMap.Data)
-	return statusKeysToRemove, &mergeStats{
-		NumUpdatedKeys:  numUpdatedKeys,
-		NumMergeSources: len(mergeSources.Items),
-		FieldsErrorMsgs: errorsOnFields,
-	}, nil
+	contributions, err := listContributions(ctx, r.Client, mergeSources.Items)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	var suspended []types.NamespacedName
+	for i := range mergeSources.Items {
+		if ms := &mergeSources.Items[i]; ms.Spec.Suspend && ms.GetDeletionTimestamp().IsZero() {
+			suspended = append(suspended, util.ObjectNamespacedName(ms))
+		}
+	}
+
+	return contributions, suspended, len(mergeSources.Items), nil
+}
+
+// initFromValues loads the initial values of the keys using initFrom.
+func (r *MergeTargetReconciler) initFromValues(ctx context.Context, mt *MergeTarget) (map[string]string, error) {
+	inits := map[string]string{}
+	for k, v := range mt.Spec.Data {
+		name, ok, err := mt.InitFromName(k)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid initFrom of %s", k)
+		} else if !ok {
+			continue
+		}
+
+		var cm corev1.ConfigMap
+		if err := r.targetReader().Get(ctx, name, &cm); err != nil {
+			return nil, errors.Wrapf(err, "failed fetching initFrom of %s", k)
+		}
+
+		value, ok := cm.Data[v.InitFrom.Key]
+		if !ok {
+			return nil, errors.Errorf("initFrom of %s: key %s missing in %s", k, v.InitFrom.Key, name)
+		}
+
+		inits[k] = value
+	}
+
+	return inits, nil
+}
+
+// inputDigest is the digest of everything the result of reconcileMergeTarget depends on.
+//
+// The spec is covered by the generation, the target ConfigMap by its
+// resourceVersion, and requests by their annotations.
+func inputDigest(
+	mt *MergeTarget, cm *corev1.ConfigMap, contributions []cmmcv1beta1.Contribution, inits map[string]string,
+) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%d\n%s/%s\n", mt.Generation, cm.UID, cm.ResourceVersion)
+	for _, c := range contributions {
+		fmt.Fprintf(&b, "%s\n%s\n%s\n%s\n%t\n", c.Key, c.MergeSource, c.ConfigMap, util.Digest(c.Data), c.DryRun)
+	}
+
+	keys := make([]string, 0, len(inits))
+	for k := range inits {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s\n%s\n", k, util.Digest(inits[k]))
+	}
+
+	for _, a := range requestAnnotations {
+		fmt.Fprintf(&b, "%s\n", mt.GetAnnotations()[a.String()])
+	}
+
+	return util.Digest(b.String())
 }
 
 func (r *MergeTargetReconciler) targetConfigMap(
-	ctx context.Context, mergeTarget *MergeTarget, name types.NamespacedName, managedByName string,
+	ctx context.Context, mergeTarget *MergeTarget, name types.NamespacedName, saveState func() error,
 ) (*corev1.ConfigMap, bool, error) {
 	var cm corev1.ConfigMap
-	if err := r.Get(ctx, name, &cm); err != nil {
+	if err := r.getTarget(ctx, name, &cm); err != nil {
 		if !apierrors.IsNotFound(err) {
 			return nil, false, errors.Wrap(err, "error fetching lonfigMap")
 		}
 
-		if err := r.maybeSetNewlyCreated(
-			ctx,
-			mergeTarget,
-			cmmcv1beta1.DataNewlyCreatedStatusYes,
-		); err != nil {
-			return nil, true, errors.WithStack(err)
+		if mergeTarget.Status.NewlyCreated != "" {
+			// we were managing it, and someone deleted it, so everything we
+			// knew about it (the initial state) is gone with it.
+			r.EventRecorder.Eventf(mergeTarget, corev1.EventTypeWarning, "TargetDeleted",
+				"Target ConfigMap %s was deleted while it was managed", name)
+			mergeTarget.SetStatusCondition(cmmcv1beta1.MergeTargetConditionTargetDeleted(
+				name.String(), mergeTarget.Spec.RecreatePolicy, mergeTarget.Generation,
+			))
+			mergeTarget.ResetStatus(name.String())
+		}
+
+		if mergeTarget.Spec.RecreatePolicy == cmmcv1beta1.RecreatePolicyNever &&
+			mergeTarget.FindStatusCondition(cmmcv1beta1.MergeTargetConditionTypeTargetDeleted) != nil {
+			// creating the ConfigMap again will trigger a new reconcile.
+			mergeTarget.SetStatusCondition(cmmcv1beta1.MergeTargetConditionWaitingForTarget(name.String()))
+			return nil, false, nil
+		}
+
+		newlyCreated := mergeTarget.Status.NewlyCreated
+		maybeSetNewlyCreated(mergeTarget, cmmcv1beta1.DataNewlyCreatedStatusYes)
+		if err := saveState(); err != nil {
+			return nil, false, err
 		}
 
 		cm = emptyConfigMap(name)
-		if err := r.Create(ctx, &cm); err != nil {
+		if err := r.Create(ctx, &cm, client.FieldOwner(fieldManager)); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				// someone else created it in the meantime, adopt it instead.
+				mergeTarget.Status.NewlyCreated = newlyCreated
+				return nil, true, nil
+			}
+
 			return nil, true, errors.Wrap(err, "failed to create target configMap")
 		}
 
 		log.FromContext(ctx).Info("created target-cm", "name", name.String())
 	}
 
-	if err := r.maybeSetNewlyCreated(
-		ctx,
-		mergeTarget,
-		cmmcv1beta1.DataNewlyCreatedStatusNo,
-	); err != nil {
-		return nil, true, err
-	}
+	maybeSetNewlyCreated(mergeTarget, cmmcv1beta1.DataNewlyCreatedStatusNo)
 
-	requeue, err := r.ensureManagedByAnnotation(ctx, &cm, managedByName)
-	if err != nil {
-		return nil, requeue, err
+	// the deleted target is only reported until the spec changes.
+	if c := mergeTarget.FindStatusCondition(cmmcv1beta1.MergeTargetConditionTypeTargetDeleted); c != nil &&
+		c.ObservedGeneration != mergeTarget.Generation {
+		mergeTarget.RemoveStatusCondition(cmmcv1beta1.MergeTargetConditionTypeTargetDeleted)
 	}
 
 	return &cm, false, nil
 }
 
-var errMisconfiguredTargetConfigMap = errors.New("misconfigured target ConfigMap")
-
-func (r *MergeTargetReconciler) ensureManagedByAnnotation(
-	ctx context.Context, cm *corev1.ConfigMap, managedByName string,
-) (bool, error) {
-	managedBy, exists := managedByMergeTarget.ParseObjectName(cm)
-	if !exists {
-		err := anns.Apply(ctx, r.Client, cm, managedByMergeTarget.Add(managedByName))
-		return true, errors.WithStack(err)
+// managedTargetName is the name of the target ConfigMap that is currently
+// managed, which is only different from spec.target while moving to a new one.
+func managedTargetName(mt *MergeTarget, specTarget types.NamespacedName) types.NamespacedName {
+	if mt.Status.Target == "" {
+		return specTarget
 	}
 
-	if managedBy.String() != managedByName {
-		return false, fmt.Errorf("cm managed by %s: %w", managedBy, errMisconfiguredTargetConfigMap)
+	name, err := util.NamespacedName(mt.Status.Target, "")
+	if err != nil {
+		return specTarget
 	}
 
-	return false, nil
+	return name
 }
 
-func (r *MergeTargetReconciler) maybeSetNewlyCreated(
-	ctx context.Context, t *MergeTarget, to string,
+// moveTarget releases the previously managed target ConfigMap if spec.target
+// changed, the same way as if the MergeTarget was deleted, and resets the status
+// so the new one is adopted from scratch.
+func (r *MergeTargetReconciler) moveTarget(
+	ctx context.Context, mtName string, mt *MergeTarget, targetName types.NamespacedName,
 ) error {
-	if t.Status.NewlyCreated != "" {
+	previous := managedTargetName(mt, targetName)
+	if previous == targetName {
+		mt.Status.Target = targetName.String()
 		return nil
 	}
 
-	t.Status.NewlyCreated = to
-	return errors.WithStack(r.Status().Update(ctx, t))
+	log.FromContext(ctx).Info("moving to a new target", "from", previous.String(), "to", targetName.String())
+	r.EventRecorder.Eventf(mt, corev1.EventTypeNormal, "TargetChanged",
+		"Target changed from %s to %s, releasing the previous target", previous, targetName)
+
+	if err := r.finalizeDeletion(ctx, mtName, previous, mt); err != nil {
+		r.EventRecorder.Eventf(mt, corev1.EventTypeWarning, "TargetReleaseFailed",
+			"Failed releasing the previous target %s: %s", previous, err.Error())
+		return err
+	}
+
+	r.EventRecorder.Eventf(mt, corev1.EventTypeNormal, "TargetReleased",
+		"Released the previous target %s, adopting %s", previous, targetName)
+	mt.ResetStatus(targetName.String())
+	mt.RemoveStatusCondition(cmmcv1beta1.MergeTargetConditionTypeTargetDeleted)
+
+	return nil
+}
+
+func maybeSetNewlyCreated(t *MergeTarget, to string) {
+	if t.Status.NewlyCreated == "" {
+		t.Status.NewlyCreated = to
+	}
 }
 
 func (r *MergeTargetReconciler) finalizeDeletion(
-	ctx context.Context, name types.NamespacedName, t *MergeTarget,
+	ctx context.Context, mtName string, name types.NamespacedName, t *MergeTarget,
 ) error {
 	var cm corev1.ConfigMap
-	if err := r.Get(ctx, name, &cm); err != nil {
+	if err := r.getTarget(ctx, name, &cm); err != nil {
 		// if the CM doesn't exist we are probably done
 		// there might be some weird issue where it doesn't exist
 		// and it _should_-- while we are deleting the MergeTarget
@@ -290,30 +657,236 @@ func (r *MergeTargetReconciler) finalizeDeletion(
 		return errors.Wrap(client.IgnoreNotFound(err), "error fetching target configMap during deletion")
 	}
 
-	if t.IsStatusNewlyCreated() {
+	var (
+		shared = len(otherOwners(&cm, mtName)) > 0
+		policy = t.Spec.DeletionPolicy
+	)
+
+	switch {
+	case policy != cmmcv1beta1.DeletionPolicyDelete:
+	case shared:
+		r.EventRecorder.Eventf(t, corev1.EventTypeWarning, "TargetShared",
+			"Not deleting target ConfigMap %s, which is shared with other MergeTargets, reverting it instead", name)
+		policy = cmmcv1beta1.DeletionPolicyRevert
+	case !t.IsStatusNewlyCreated() && !ownsKeys(&cm, mtName):
+		// a MergeTarget that never managed the ConfigMap (e.g. it lost a
+		// conflict, or only ever ran in dry-run) has no business deleting it.
+		r.EventRecorder.Eventf(t, corev1.EventTypeWarning, "TargetNotManaged",
+			"Not deleting target ConfigMap %s, which was never managed by this MergeTarget, reverting it instead", name)
+		policy = cmmcv1beta1.DeletionPolicyRevert
+	}
+
+	switch {
+	case policy == cmmcv1beta1.DeletionPolicyRetain:
+		if err := r.retainTarget(ctx, mtName, t, &cm); err != nil {
+			return errors.Wrapf(err, "error retaining fields of target configMap %s", name)
+		}
+	case policy == cmmcv1beta1.DeletionPolicyDelete, t.IsStatusNewlyCreated() && !shared:
 		// we need to do some cleanup to this configMap, which exists
 		// simplest case is that we should be deleting this.
 		return errors.Wrap(r.Delete(ctx, &cm), "error deleting target configMap")
+	default:
+		// otherwise we have to clean up all the fields!
+		if err := r.releaseTarget(ctx, mtName, t, &cm); err != nil {
+			return errors.Wrapf(err, "error reverting fields of target configMap %s", name)
+		}
+	}
+
+	return errors.Wrapf(
+		releaseKeys(ctx, r.Client, &cm, mtName, nil, true), "error releasing keys of target configMap %s", name,
+	)
+}
+
+// writeTarget applies the keys the MergeTarget manages to the target ConfigMap.
+//
+// Keys that it stops managing, and existed before the MergeTarget did, are
+// handed over to the baseline field manager first so they are reverted to their
+// initial value rather than removed.
+func (r *MergeTargetReconciler) writeTarget(
+	ctx context.Context, mtName string, mt *MergeTarget, cm *corev1.ConfigMap, keysToRemove, keptKeys []string,
+) error {
+	var (
+		removed  = map[string]struct{}{}
+		baseline = map[string]string{}
+		managed  = map[string]string{}
+	)
+
+	for _, k := range keysToRemove {
+		removed[k] = struct{}{}
+		if v := mt.Status.Data[k]; !v.IsStatusNewlyCreated() {
+			baseline[k] = v.Init
+		}
+	}
+
+	for k := range mt.Status.Data {
+		// kept drifted keys are left to whoever modified them.
+		if _, ok := removed[k]; ok || util.ContainsString(keptKeys, k) {
+			continue
+		}
+
+		if v, ok := cm.Data[k]; ok {
+			managed[k] = v
+		}
+	}
+
+	if err := applyBaseline(ctx, r.Client, cm, baseline); err != nil {
+		return err
+	}
+
+	if err := applyTarget(ctx, r.Client, cm, targetFieldManager(mtName), targetApplyConfig{Data: managed}); err != nil {
+		return err
+	} else if err := dropBaseline(ctx, r.Client, cm); err != nil {
+		return err
+	}
+
+	return releaseKeys(ctx, r.Client, cm, mtName, keysToRemove, false)
+}
+
+// holdKeys records why the keys are held back, unless they already are.
+func holdKeys(held map[string]string, keys []string, reason string) {
+	for _, k := range keys {
+		if _, ok := held[k]; !ok {
+			held[k] = reason
+		}
 	}
+}
+
+// keepValues restores the current values of the keys in the merged data, and
+// returns the number of keys that are no longer updated.
+func keepValues(keys []string, live, data map[string]string) int {
+	var unchanged int
+	for _, k := range keys {
+		v, ok := live[k]
+		if w, was := data[k]; ok == was && v == w {
+			continue
+		} else if ok {
+			data[k] = v
+		} else {
+			delete(data, k)
+		}
+
+		unchanged++
+	}
+
+	return unchanged
+}
+
+// isApplied checks if the MergeTarget has applied the target ConfigMap.
+func isApplied(cm *corev1.ConfigMap, mtName string) bool {
+	for _, entry := range cm.GetManagedFields() {
+		if entry.Manager == targetFieldManager(mtName) && entry.Operation == metav1.ManagedFieldsOperationApply {
+			return true
+		}
+	}
+
+	return false
+}
+
+// releaseTarget reverts the keys of an existing target ConfigMap to their
+// initial values, and gives up the ownership of everything else.
+func (r *MergeTargetReconciler) releaseTarget(
+	ctx context.Context, mtName string, t *MergeTarget, cm *corev1.ConfigMap,
+) error {
+	var (
+		baseline = map[string]string{}
+		created  []string
+	)
 
-	// otherwise we have to clean up all the fields!
 	for k, v := range t.Status.Data {
 		if v.IsStatusNewlyCreated() {
-			delete(cm.Data, k)
+			created = append(created, k)
 		} else {
-			cm.Data[k] = v.Init
+			baseline[k] = v.Init
+		}
+	}
+
+	if err := applyBaseline(ctx, r.Client, cm, baseline); err != nil {
+		return err
+	}
+
+	if err := applyTarget(ctx, r.Client, cm, targetFieldManager(mtName), targetApplyConfig{}); err != nil {
+		return err
+	} else if err := dropBaseline(ctx, r.Client, cm); err != nil {
+		return err
+	}
+
+	// Targets written before every MergeTarget applied its own keys aren't
+	// owned by it, so make sure the created keys are really gone.
+	patch := client.MergeFrom(cm.DeepCopy())
+	changed := false
+	for _, k := range created {
+		if _, ok := cm.Data[k]; ok {
+			delete(cm.Data, k)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return errors.WithStack(r.Patch(ctx, cm, patch, client.FieldOwner(fieldManager)))
+}
+
+// retainTarget gives up the ownership of the keys of the target ConfigMap,
+// keeping their current values.
+//
+// The values are handed to the baseline field manager, otherwise giving up
+// the ownership would remove them, which then drops them too.
+func (r *MergeTargetReconciler) retainTarget(
+	ctx context.Context, mtName string, t *MergeTarget, cm *corev1.ConfigMap,
+) error {
+	values := map[string]string{}
+	for k := range t.Status.Data {
+		if v, ok := cm.Data[k]; ok {
+			values[k] = v
 		}
 	}
 
-	// remove the annotation
-	anns.Set(&cm, managedByMergeTarget.Remove())
+	if err := applyBaseline(ctx, r.Client, cm, values); err != nil {
+		return err
+	} else if err := applyTarget(ctx, r.Client, cm, targetFieldManager(mtName), targetApplyConfig{}); err != nil {
+		return err
+	}
+
+	return dropBaseline(ctx, r.Client, cm)
+}
+
+// targetReader is the client.Reader target ConfigMaps are read with.
+func (r *MergeTargetReconciler) targetReader() client.Reader {
+	if r.UncachedTargets {
+		return r.apiReader()
+	}
+
+	return r.Client
+}
+
+// apiReader is the client.Reader that reads directly from the API server, if
+// there is one.
+func (r *MergeTargetReconciler) apiReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+
+	return r.Client
+}
+
+// getTarget reads a target ConfigMap. A ConfigMap missing from the cache is
+// read from the API server, since the cache may not have caught up with it
+// yet (e.g. right after it was created).
+func (r *MergeTargetReconciler) getTarget(ctx context.Context, name types.NamespacedName, cm *corev1.ConfigMap) error {
+	err := r.targetReader().Get(ctx, name, cm)
+	if apierrors.IsNotFound(err) && !r.UncachedTargets && r.APIReader != nil {
+		err = r.apiReader().Get(ctx, name, cm)
+	}
 
-	// perform the udpate
-	return errors.Wrapf(r.Update(ctx, &cm), "error reverting fields of target configMap %s", name)
+	return errors.WithStack(err)
 }
 
 const (
 	fieldIndexStatusTarget = "status.target"
+	fieldIndexSpecTarget   = "spec.target"
+	fieldIndexInitFrom     = "spec.data.initFrom"
 )
 
 func resourceStatusTargetIndexer(o client.Object) []string {
@@ -324,6 +897,85 @@ func resourceStatusTargetIndexer(o client.Object) []string {
 	return []string{target.String()}
 }
 
+func mergeTargetSpecTargetIndexer(o client.Object) []string {
+	mt, ok := o.(*MergeTarget)
+	if !ok {
+		return nil
+	}
+
+	target, err := mt.NamespacedTargetName()
+	if err != nil {
+		return nil
+	}
+
+	return []string{target.String()}
+}
+
+func mergeTargetInitFromIndexer(o client.Object) []string {
+	mt, ok := o.(*MergeTarget)
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for k := range mt.Spec.Data {
+		if name, ok, err := mt.InitFromName(k); ok && err == nil && !util.ContainsString(names, name.String()) {
+			names = append(names, name.String())
+		}
+	}
+
+	return names
+}
+
+// mergeTargetsForConfigMap maps a ConfigMap to the MergeTargets managing it,
+// targeting it (but not managing it yet, for example because of a conflict),
+// or loading initial values from it.
+func (r *MergeTargetReconciler) mergeTargetsForConfigMap(o client.Object) []reconcile.Request {
+	var (
+		ctx  = context.Background()
+		reqs []reconcile.Request
+		seen = map[types.NamespacedName]struct{}{}
+	)
+
+	add := func(n types.NamespacedName) {
+		if _, ok := seen[n]; !ok {
+			seen[n] = struct{}{}
+			reqs = append(reqs, reconcile.Request{NamespacedName: n})
+		}
+	}
+
+	for _, n := range managedByMergeTarget.ParseObjectNames(o) {
+		add(n)
+	}
+
+	var mergeTargets cmmcv1beta1.MergeTargetList
+	if err := r.List(
+		ctx, &mergeTargets, client.MatchingFields{fieldIndexSpecTarget: util.ObjectResourceName(o)},
+	); err != nil {
+		log.FromContext(ctx).Error(err, "failed listing MergeTargets for ConfigMap", "config-map", util.ObjectResourceName(o))
+		return reqs
+	}
+
+	for _, mt := range mergeTargets.Items {
+		mt := mt
+		add(util.ObjectNamespacedName(&mt))
+	}
+
+	if err := r.List(
+		ctx, &mergeTargets, client.MatchingFields{fieldIndexInitFrom: util.ObjectResourceName(o)},
+	); err != nil {
+		log.FromContext(ctx).Error(err, "failed listing MergeTargets for ConfigMap", "config-map", util.ObjectResourceName(o))
+		return reqs
+	}
+
+	for _, mt := range mergeTargets.Items {
+		mt := mt
+		add(util.ObjectNamespacedName(&mt))
+	}
+
+	return reqs
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *MergeTargetReconciler) SetupWithManager(mgr ctrl.Manager, opts controller.Options) error {
 	ctx := context.Background()
@@ -333,13 +985,29 @@ func (r *MergeTargetReconciler) SetupWithManager(mgr ctrl.Manager, opts controll
 		return errors.Wrapf(err, "error setting field indexer for field = %s", fieldIndexStatusTarget)
 	}
 
+	if err := mgr.GetFieldIndexer().IndexField(
+		ctx, &cmmcv1beta1.MergeTarget{}, fieldIndexSpecTarget, mergeTargetSpecTargetIndexer,
+	); err != nil {
+		return errors.Wrapf(err, "error setting field indexer for field = %s", fieldIndexSpecTarget)
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(
+		ctx, &cmmcv1beta1.MergeTarget{}, fieldIndexInitFrom, mergeTargetInitFromIndexer,
+	); err != nil {
+		return errors.Wrapf(err, "error setting field indexer for field = %s", fieldIndexInitFrom)
+	}
+
 	return errors.WithStack(
 		ctrl.NewControllerManagedBy(mgr).
 			For(&cmmcv1beta1.MergeTarget{}).
 			WithOptions(opts).
 			Watches(
 				&source.Kind{Type: &corev1.ConfigMap{}},
-				watchReconciliationEventHandler(managedByMergeTarget.ParseObjectName),
+				handler.EnqueueRequestsFromMapFunc(r.mergeTargetsForConfigMap),
+			).
+			Watches(
+				&source.Kind{Type: &cmmcv1beta1.MergeTarget{}},
+				handler.EnqueueRequestsFromMapFunc(mergeTargetsForHandover),
 			).
 			Watches(
 				&source.Kind{Type: &cmmcv1beta1.MergeSource{}},
@@ -0,0 +1,180 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cashapp/cmmc/util"
+	anns "github.com/cashapp/cmmc/util/annotations"
+)
+
+// keyOwners maps the data keys of a target ConfigMap to the MergeTarget
+// managing them, as stored in the managedKeys annotation.
+type keyOwners map[string]string
+
+// parseKeyOwners reads the key owners from the ConfigMap, ignoring malformed entries.
+func parseKeyOwners(cm *corev1.ConfigMap) keyOwners {
+	owners := keyOwners{}
+	for _, entry := range strings.Split(cm.GetAnnotations()[managedKeys.String()], ",") {
+		key, owner, ok := strings.Cut(entry, "=")
+		if ok && key != "" && owner != "" {
+			owners[key] = owner
+		}
+	}
+
+	return owners
+}
+
+// String formats the key owners as they are stored in the annotation, sorted by key.
+func (o keyOwners) String() string {
+	entries := make([]string, 0, len(o))
+	for k, v := range o {
+		entries = append(entries, k+"="+v)
+	}
+
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// keyConflictError is returned when a MergeTarget claims a key managed by another one.
+type keyConflictError struct {
+	Key   string
+	Owner string
+}
+
+func (e *keyConflictError) Error() string {
+	return fmt.Sprintf("key %s is managed by MergeTarget %s", e.Key, e.Owner)
+}
+
+// claimedKeys are the keys a MergeTarget needs to own, the ones in its spec
+// (unless they were handed over), and the ones it still has to revert.
+func claimedKeys(mt *MergeTarget) []string {
+	keys := make([]string, 0, len(mt.Spec.Data)+len(mt.Status.Data))
+	for k := range mt.Spec.Data {
+		if _, ok := mt.Status.HandedOver[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	for k := range mt.Status.Data {
+		if _, ok := mt.Spec.Data[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// claimTarget records the MergeTarget as the owner of its keys on the target ConfigMap.
+//
+// If any of the keys are owned by another MergeTarget it returns a
+// *keyConflictError, and claims nothing. A MergeTarget without any keys
+// isn't managing the ConfigMap.
+func claimTarget(ctx context.Context, c client.Client, cm *corev1.ConfigMap, mtName string, keys []string) error {
+	owners := parseKeyOwners(cm)
+	for _, k := range keys {
+		if owner, ok := owners[k]; ok && owner != mtName {
+			return &keyConflictError{Key: k, Owner: owner}
+		}
+	}
+
+	for _, k := range keys {
+		owners[k] = mtName
+	}
+
+	managedBy := managedByMergeTarget.AddToList(mtName)
+	if len(keys) == 0 {
+		managedBy = managedByMergeTarget.RemoveFromList(mtName)
+	}
+
+	return patchOwnership(ctx, c, cm, managedBy, setKeyOwners(owners))
+}
+
+// releaseKeys removes the MergeTarget as the owner of the keys.
+//
+// If all is set, it also stops being one of the MergeTargets managing the ConfigMap.
+func releaseKeys(
+	ctx context.Context, c client.Client, cm *corev1.ConfigMap, mtName string, keys []string, all bool,
+) error {
+	owners := parseKeyOwners(cm)
+	for k, owner := range owners {
+		if owner == mtName && (all || util.ContainsString(keys, k)) {
+			delete(owners, k)
+		}
+	}
+
+	fns := []anns.UpdateFn{setKeyOwners(owners)}
+	if all {
+		fns = append(fns, managedByMergeTarget.RemoveFromList(mtName))
+	}
+
+	return patchOwnership(ctx, c, cm, fns...)
+}
+
+// otherOwners returns the other MergeTargets managing the ConfigMap.
+func otherOwners(cm *corev1.ConfigMap, mtName string) []string {
+	var names []string
+	for _, n := range managedByMergeTarget.ParseObjectNames(cm) {
+		if n.String() != mtName {
+			names = append(names, n.String())
+		}
+	}
+
+	for _, owner := range parseKeyOwners(cm) {
+		if owner != mtName && !util.ContainsString(names, owner) {
+			names = append(names, owner)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// ownsKeys checks if the MergeTarget is the owner of any key of the ConfigMap.
+func ownsKeys(cm *corev1.ConfigMap, mtName string) bool {
+	for _, owner := range parseKeyOwners(cm) {
+		if owner == mtName {
+			return true
+		}
+	}
+
+	return false
+}
+
+func setKeyOwners(owners keyOwners) anns.UpdateFn {
+	if len(owners) == 0 {
+		return managedKeys.Remove()
+	}
+
+	return anns.Add(managedKeys.String(), owners.String())
+}
+
+// patchOwnership applies the ownership annotations of the ConfigMap.
+//
+// The apply is conditional on the resourceVersion, so that concurrent claims
+// of the same keys can't both succeed.
+func patchOwnership(ctx context.Context, c client.Client, cm *corev1.ConfigMap, fns ...anns.UpdateFn) error {
+	return errors.WithStack(anns.Patch(ctx, c, cm, fieldManager, fns...))
+}
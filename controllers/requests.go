@@ -0,0 +1,113 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
+	anns "github.com/cashapp/cmmc/util/annotations"
+)
+
+// requestAnnotations are the annotations asking a MergeTarget for a one-off
+// operation. Their value is an arbitrary token (e.g. a timestamp), and every
+// new token is handled once.
+var requestAnnotations = []anns.Annotation{
+	rebaselineRequest, acknowledgeDrift, reconcileRequest, acknowledgeRemoval,
+}
+
+// pendingRequest returns the token of the request annotation, if it wasn't handled yet.
+func pendingRequest(mt *MergeTarget, a anns.Annotation) (string, bool) {
+	token := mt.GetAnnotations()[a.String()]
+	if token == "" || mt.Status.HandledRequests[a.String()] == token {
+		return "", false
+	}
+
+	return token, true
+}
+
+// markRequestHandled records the token of the request annotation as handled.
+func markRequestHandled(mt *MergeTarget, a anns.Annotation, token string) {
+	if mt.Status.HandledRequests == nil {
+		mt.Status.HandledRequests = map[string]string{}
+	}
+
+	mt.Status.HandledRequests[a.String()] = token
+}
+
+// rebaseline sets the initial value of every key the MergeTarget adopted to
+// its current value without the contributions, if it was asked to.
+//
+// Keys created by the MergeTarget, or loading their initial value with
+// initFrom, are left alone. If any of the current values doesn't end with the
+// contributions that are merged nothing is re-baselined.
+func (r *MergeTargetReconciler) rebaseline(
+	ctx context.Context, mt *MergeTarget, cm *corev1.ConfigMap,
+	contributions []cmmcv1beta1.Contribution, lastKnownGood cmmcv1beta1.LastKnownGood,
+) {
+	token, ok := pendingRequest(mt, rebaselineRequest)
+	if !ok {
+		return
+	}
+
+	defer markRequestHandled(mt, rebaselineRequest, token)
+
+	var (
+		inits   = map[string]string{}
+		refused []string
+	)
+
+	for k, v := range mt.Status.Data {
+		if _, ok := mt.Spec.Data[k]; !ok || v.IsStatusNewlyCreated() || mt.Spec.Data[k].InitFrom != nil {
+			continue
+		}
+
+		var suffix string
+		for _, c := range mt.MergedContributions(k, contributions, lastKnownGood) {
+			suffix += c.Data
+		}
+
+		if current := cm.Data[k]; strings.HasSuffix(current, suffix) {
+			inits[k] = strings.TrimSuffix(current, suffix)
+		} else {
+			refused = append(refused, k)
+		}
+	}
+
+	if len(refused) > 0 {
+		sort.Strings(refused)
+		mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionRebaselineRefused(refused))
+		r.EventRecorder.Eventf(mt, corev1.EventTypeWarning, "RebaselineRefused",
+			"Not re-baselining, current values of %s don't end with the contributions", refused)
+		return
+	}
+
+	keys := make([]string, 0, len(inits))
+	for k := range inits {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	log.FromContext(ctx).Info("re-baselining", "keys", keys)
+	mt.SetInits(inits)
+	mt.SetStatusCondition(cmmcv1beta1.MergeTargetConditionRebaselined(keys))
+	r.EventRecorder.Eventf(mt, corev1.EventTypeNormal, "Rebaselined", "Re-baselined the initial values of %s", keys)
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
+	"github.com/cashapp/cmmc/util"
+	"github.com/cashapp/cmmc/util/diff"
+)
+
+const (
+	// revisionOfLabel labels the ControllerRevisions of a MergeTarget with its UID.
+	revisionOfLabel = "config.cmmc.k8s.cash.app/revision-of"
+
+	defaultRevisionHistoryLimit = 10
+)
+
+// RevisionSelector selects the ControllerRevisions kept by cmmc, the only ones
+// the controller needs to cache.
+func RevisionSelector() labels.Selector {
+	req, err := labels.NewRequirement(revisionOfLabel, selection.Exists, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return labels.NewSelector().Add(*req)
+}
+
+// targetRevision is a revision of the data a MergeTarget wrote to its target
+// ConfigMap, stored in a ControllerRevision.
+type targetRevision struct {
+	Target  string            `json:"target"`
+	Digest  string            `json:"digest"`
+	Data    map[string]string `json:"data,omitempty"`
+	Sources []revisionSource  `json:"sources,omitempty"`
+
+	// Diff is the diff from the data of the previous revision.
+	Diff string `json:"diff,omitempty"`
+}
+
+// revisionSource is a source ConfigMap contributing to a revision.
+type revisionSource struct {
+	Key             string `json:"key"`
+	MergeSource     string `json:"mergeSource"`
+	ConfigMap       string `json:"configMap"`
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+func parseTargetRevision(cr *appsv1.ControllerRevision) (targetRevision, error) {
+	var rev targetRevision
+	if err := json.Unmarshal(cr.Data.Raw, &rev); err != nil {
+		return targetRevision{}, errors.Wrapf(err, "failed decoding revision %d", cr.Revision)
+	}
+
+	return rev, nil
+}
+
+// revisions lists the ControllerRevisions of the MergeTarget, oldest first.
+func (r *MergeTargetReconciler) revisions(ctx context.Context, mt *MergeTarget) ([]appsv1.ControllerRevision, error) {
+	var list appsv1.ControllerRevisionList
+	if err := r.List(
+		ctx, &list, client.InNamespace(mt.Namespace), client.MatchingLabels{revisionOfLabel: string(mt.UID)},
+	); err != nil {
+		return nil, errors.Wrap(err, "failed listing revisions")
+	}
+
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Revision < list.Items[j].Revision })
+	return list.Items, nil
+}
+
+// revision returns the data of the given revision of the MergeTarget.
+func (r *MergeTargetReconciler) revision(ctx context.Context, mt *MergeTarget, number int64) (targetRevision, error) {
+	revisions, err := r.revisions(ctx, mt)
+	if err != nil {
+		return targetRevision{}, err
+	}
+
+	for i := range revisions {
+		if revisions[i].Revision == number {
+			return parseTargetRevision(&revisions[i])
+		}
+	}
+
+	return targetRevision{}, errors.Errorf("revision %d not found", number)
+}
+
+// recordRevision records the data of the keys the MergeTarget wrote to the
+// target ConfigMap as a new revision, unless it is the same as the latest
+// one, and prunes the revisions beyond the history limit.
+func (r *MergeTargetReconciler) recordRevision(
+	ctx context.Context, mt *MergeTarget, cm *corev1.ConfigMap, contributions []cmmcv1beta1.Contribution,
+) error {
+	limit := defaultRevisionHistoryLimit
+	if mt.Spec.RevisionHistoryLimit != nil {
+		limit = int(*mt.Spec.RevisionHistoryLimit)
+	}
+
+	revisions, err := r.revisions(ctx, mt)
+	if err != nil {
+		return err
+	}
+
+	rev := targetRevision{Target: util.ObjectResourceName(cm), Data: map[string]string{}}
+	for k := range mt.Spec.Data {
+		if v, ok := cm.Data[k]; ok {
+			rev.Data[k] = v
+		}
+	}
+
+	encodedData, err := json.Marshal(rev.Data)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	rev.Digest = util.Digest(string(encodedData))
+
+	// revision numbers keep increasing, even if all revisions were pruned.
+	var (
+		number   = mt.Status.CurrentRevision + 1
+		previous targetRevision
+	)
+
+	if n := len(revisions); n > 0 {
+		if previous, err = parseTargetRevision(&revisions[n-1]); err != nil {
+			return err
+		} else if revisions[n-1].Revision >= number {
+			number = revisions[n-1].Revision + 1
+		}
+	}
+
+	switch {
+	case limit == 0:
+		mt.Status.CurrentRevision = 0
+	case len(revisions) > 0 && previous.Digest == rev.Digest && previous.Target == rev.Target:
+		mt.Status.CurrentRevision = revisions[len(revisions)-1].Revision
+	default:
+		for _, c := range contributions {
+			if c.DryRun {
+				continue
+			}
+
+			rev.Sources = append(rev.Sources, revisionSource{
+				Key:             c.Key,
+				MergeSource:     c.MergeSource.String(),
+				ConfigMap:       c.ConfigMap.String(),
+				ResourceVersion: c.ResourceVersion,
+			})
+		}
+
+		rev.Diff = diff.Maps(previous.Data, rev.Data)
+		if err := r.createRevision(ctx, mt, number, rev); err != nil {
+			return err
+		}
+
+		log.FromContext(ctx).Info("recorded revision", "revision", number, "digest", rev.Digest)
+		revisions = append(revisions, appsv1.ControllerRevision{Revision: number})
+		mt.Status.CurrentRevision = number
+	}
+
+	for i := 0; i < len(revisions)-limit; i++ {
+		old := revisions[i]
+		if mt.Spec.RollbackTo != nil && *mt.Spec.RollbackTo == old.Revision {
+			continue
+		}
+
+		if err := r.Delete(ctx, &old); client.IgnoreNotFound(err) != nil {
+			return errors.Wrapf(err, "failed pruning revision %d", old.Revision)
+		}
+	}
+
+	return nil
+}
+
+func (r *MergeTargetReconciler) createRevision(
+	ctx context.Context, mt *MergeTarget, number int64, rev targetRevision,
+) error {
+	encoded, err := json.Marshal(rev)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	name := companionName(mt, fmt.Sprintf("-cmmc-revision-%d", number))
+	cr := &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels:    map[string]string{revisionOfLabel: string(mt.UID)},
+		},
+		Data:     runtime.RawExtension{Raw: encoded},
+		Revision: number,
+	}
+
+	if err := controllerutil.SetControllerReference(mt, cr, r.Scheme); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.Wrapf(r.Create(ctx, cr, client.FieldOwner(fieldManager)), "failed creating revision %d", number)
+}
+
+// rollBack replaces the merged data of the keys in the revision the
+// MergeTarget is pinned to with their data in that revision, and returns the
+// number of keys that changed.
+func (r *MergeTargetReconciler) rollBack(
+	ctx context.Context, mt *MergeTarget, live, data map[string]string,
+) (int, error) {
+	rev, err := r.revision(ctx, mt, *mt.Spec.RollbackTo)
+	if err != nil {
+		return 0, err
+	}
+
+	for k := range mt.Spec.Data {
+		if v, ok := rev.Data[k]; ok {
+			data[k] = v
+		} else {
+			keepValues([]string{k}, live, data)
+		}
+	}
+
+	return len(diff.ChangedKeys(live, data)), nil
+}
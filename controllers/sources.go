@@ -0,0 +1,127 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
+	"github.com/cashapp/cmmc/util"
+)
+
+// listSources lists the source ConfigMaps of the MergeSource, sorted by their
+// namespace/name so that the accumulated data is deterministic.
+func listSources(
+	ctx context.Context, c client.Reader, s *cmmcv1beta1.MergeSource,
+) ([]corev1.ConfigMap, error) {
+	var sources corev1.ConfigMapList
+	if err := c.List(ctx, &sources, client.MatchingLabels(s.Spec.Selector)); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(sources.Items) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(sources.Items, func(i, j int) bool {
+		return util.ObjectResourceName(&sources.Items[i]) < util.ObjectResourceName(&sources.Items[j])
+	})
+
+	namespaceSelector := s.NamespaceSelector()
+	if len(namespaceSelector) == 0 {
+		return sources.Items, nil
+	}
+
+	var nsList corev1.NamespaceList
+	if err := c.List(ctx, &nsList, client.MatchingLabels(namespaceSelector)); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(nsList.Items) == 0 {
+		log.FromContext(ctx).Info("[WARN] found no matching namespaces, filtering all configMaps", "selector", namespaceSelector)
+		return nil, nil
+	}
+
+	nsMap := map[string]struct{}{}
+	for _, ns := range nsList.Items {
+		nsMap[ns.GetName()] = struct{}{}
+	}
+
+	n := 0
+	for _, cm := range sources.Items {
+		_, ok := nsMap[cm.GetNamespace()]
+		if ok {
+			sources.Items[n] = cm
+			n++
+		}
+	}
+
+	return sources.Items[:n], nil
+}
+
+// listContributions reads the contributions of every MergeSource directly from
+// their source ConfigMaps, rather than from the MergeSource status.
+//
+// MergeSources are sorted by namespace/name, and their ConfigMaps as in listSources.
+func listContributions(
+	ctx context.Context, c client.Reader, mergeSources []cmmcv1beta1.MergeSource,
+) ([]cmmcv1beta1.Contribution, error) {
+	sort.Slice(mergeSources, func(i, j int) bool {
+		return util.ObjectResourceName(&mergeSources[i]) < util.ObjectResourceName(&mergeSources[j])
+	})
+
+	var contributions []cmmcv1beta1.Contribution
+	for i := range mergeSources {
+		ms := &mergeSources[i]
+		if !ms.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		sources, err := listSources(ctx, c, ms)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed listing sources of MergeSource %s", util.ObjectResourceName(ms))
+		}
+
+		for _, cm := range sources {
+			cm := cm
+			contributions = append(contributions, cmmcv1beta1.Contribution{
+				Key:         ms.Spec.Target.Data,
+				MergeSource: util.ObjectNamespacedName(ms),
+				ConfigMap:   util.ObjectNamespacedName(&cm),
+				Data:        cm.Data[ms.Spec.Source.Data],
+
+				ResourceVersion: cm.ResourceVersion,
+				UID:             cm.UID,
+				AnnotateSource:  ms.Spec.AnnotateSources,
+				DryRun:          isDryRunSource(&cm),
+			})
+		}
+	}
+
+	return contributions, nil
+}
+
+// isDryRunSource returns true if the source ConfigMap only asks for its
+// contribution to be validated, and not merged.
+func isDryRunSource(cm *corev1.ConfigMap) bool {
+	return cm.GetAnnotations()[dryRunSource.String()] == "true"
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
+	"github.com/cashapp/cmmc/util"
+)
+
+const (
+	stateConfigMapSuffix = "-cmmc-state"
+	stateKey             = "state"
+	stateDigestKey       = "digest"
+)
+
+// revertState is everything about the target ConfigMap a MergeTarget needs
+// to be able to revert it.
+//
+// It is kept in the status, and backed up in a state ConfigMap next to the
+// MergeTarget, since the status is usually not part of backups.
+type revertState struct {
+	Target       string                                       `json:"target,omitempty"`
+	NewlyCreated string                                       `json:"newlyCreated,omitempty"`
+	Data         map[string]cmmcv1beta1.MergeTargetDataStatus `json:"data,omitempty"`
+	HandedOver   map[string]string                            `json:"handedOver,omitempty"`
+
+	// HandledRequests are backed up too, otherwise requests that were handled
+	// already (e.g. acknowledging a removal) would be handled again.
+	HandledRequests map[string]string `json:"handledRequests,omitempty"`
+}
+
+func revertStateOf(mt *MergeTarget) revertState {
+	return revertState{
+		Target:       mt.Status.Target,
+		NewlyCreated: mt.Status.NewlyCreated,
+		Data:         mt.Status.Data,
+		HandedOver:   mt.Status.HandedOver,
+
+		HandledRequests: mt.Status.HandledRequests,
+	}
+}
+
+func (s revertState) encode() (string, string, error) {
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+
+	return string(encoded), util.Digest(string(encoded)), nil
+}
+
+// stateConfigMapName is the name of the state ConfigMap of the MergeTarget.
+func stateConfigMapName(mt *MergeTarget) types.NamespacedName {
+	return companionName(mt, stateConfigMapSuffix)
+}
+
+// companionName is the name of an object (e.g. a ConfigMap) kept next to the
+// MergeTarget, <name><suffix>, or a digest of the name if that is too long.
+func companionName(mt *MergeTarget, suffix string) types.NamespacedName {
+	name := mt.Name + suffix
+	if len(name) > validation.DNS1123SubdomainMaxLength {
+		name = strings.TrimPrefix(suffix, "-") + "-" + util.Digest(mt.Name)[:32]
+	}
+
+	return types.NamespacedName{Namespace: mt.Namespace, Name: name}
+}
+
+// restoreState restores the revert state in the status of the MergeTarget from
+// its state ConfigMap, if the status lost it, and returns the digest of the
+// backed up state.
+//
+// The state ConfigMap is always written before anything else, so it is never
+// older than the status. The cached copy is enough when it backs up the status
+// already, which is the steady state. Otherwise it is read from the API server,
+// since the cache may still have an older copy of it (or not have it at all).
+func (r *MergeTargetReconciler) restoreState(ctx context.Context, mt *MergeTarget) (string, error) {
+	_, digest, err := revertStateOf(mt).encode()
+	if err != nil {
+		return "", err
+	}
+
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, stateConfigMapName(mt), &cm); client.IgnoreNotFound(err) != nil {
+		return "", errors.Wrap(err, "failed fetching cached state configMap")
+	} else if err == nil && cm.Data[stateDigestKey] == digest {
+		return digest, nil
+	}
+
+	cm = corev1.ConfigMap{}
+	if err := r.apiReader().Get(ctx, stateConfigMapName(mt), &cm); err != nil {
+		return "", errors.Wrap(client.IgnoreNotFound(err), "failed fetching state configMap")
+	}
+
+	backupDigest := cm.Data[stateDigestKey]
+	if util.Digest(cm.Data[stateKey]) != backupDigest {
+		log.FromContext(ctx).Info("ignoring corrupt state configMap", "config-map", util.ObjectResourceName(&cm))
+		return "", nil
+	} else if digest == backupDigest {
+		return backupDigest, nil
+	}
+
+	var state revertState
+	if err := json.Unmarshal([]byte(cm.Data[stateKey]), &state); err != nil {
+		return "", errors.Wrap(err, "failed decoding state configMap")
+	}
+
+	if lost, err := r.statusLost(ctx, mt, state); err != nil || !lost {
+		// the status is more recent, it is backed up again by saveState.
+		return backupDigest, err
+	}
+
+	log.FromContext(ctx).Info("restoring status from state configMap", "config-map", util.ObjectResourceName(&cm))
+	r.EventRecorder.Eventf(mt, corev1.EventTypeNormal, "StateRestored",
+		"Restored the status of the target %s from %s", state.Target, util.ObjectResourceName(&cm))
+
+	mt.Status.Target = state.Target
+	mt.Status.NewlyCreated = state.NewlyCreated
+	mt.Status.Data = state.Data
+	mt.Status.HandedOver = state.HandedOver
+	mt.Status.HandledRequests = state.HandledRequests
+	mt.Status.InputDigest = ""
+
+	return backupDigest, nil
+}
+
+// statusLost checks if the status of the MergeTarget lost the backed up state:
+// it is empty, or it doesn't know about keys of the live target ConfigMap that
+// the MergeTarget manages.
+func (r *MergeTargetReconciler) statusLost(ctx context.Context, mt *MergeTarget, state revertState) (bool, error) {
+	if state.Target == "" {
+		return false, nil
+	} else if mt.Status.Target == "" || len(mt.Status.Data) == 0 && len(state.Data) > 0 {
+		return true, nil
+	}
+
+	name, err := util.NamespacedName(state.Target, "")
+	if err != nil {
+		return false, nil //nolint:nilerr
+	}
+
+	var target corev1.ConfigMap
+	if err := r.apiReader().Get(ctx, name, &target); err != nil {
+		return false, errors.Wrap(client.IgnoreNotFound(err), "failed fetching target configMap")
+	}
+
+	mtName := util.ObjectResourceName(mt)
+	for k, owner := range parseKeyOwners(&target) {
+		_, known := mt.Status.Data[k]
+		if _, backedUp := state.Data[k]; owner == mtName && !known && backedUp {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// saveState writes the revert state of the MergeTarget to its state
+// ConfigMap, if it changed since digest, and updates digest.
+func (r *MergeTargetReconciler) saveState(ctx context.Context, mt *MergeTarget, digest *string) error {
+	encoded, newDigest, err := revertStateOf(mt).encode()
+	if err != nil {
+		return err
+	} else if newDigest == *digest {
+		return nil
+	}
+
+	name := stateConfigMapName(mt)
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name.Name,
+			Namespace:   name.Namespace,
+			Annotations: map[string]string{stateOf.String(): util.ObjectResourceName(mt)},
+		},
+		Data: map[string]string{stateKey: encoded, stateDigestKey: newDigest},
+	}
+
+	if err := r.Patch(ctx, cm, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
+		return errors.Wrap(err, "failed saving state configMap")
+	}
+
+	*digest = newDigest
+	return nil
+}
+
+// deleteState deletes the state ConfigMap of the MergeTarget.
+func (r *MergeTargetReconciler) deleteState(ctx context.Context, mt *MergeTarget) error {
+	name := stateConfigMapName(mt)
+	err := r.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace}})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	return errors.Wrap(err, "failed deleting state configMap")
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cashapp/cmmc/util"
+)
+
+// patchStatus issues a single status patch for o if its status (as returned by
+// status) changed from base during the reconciliation.
+//
+// On a conflict the latest version of the object is read, and copyStatus
+// is used to put the desired status on top of it before trying again.
+func patchStatus[T client.Object](
+	ctx context.Context, c client.Client, base, o T,
+	status func(T) interface{}, copyStatus func(from, to T),
+) error {
+	if equality.Semantic.DeepEqual(status(base), status(o)) {
+		return nil
+	}
+
+	var (
+		desired = o.DeepCopyObject().(T)    //nolint:forcetypeassert
+		from    = base.DeepCopyObject().(T) //nolint:forcetypeassert
+		retried = false
+	)
+
+	// o might have been updated (e.g. with a finalizer) since we read base.
+	from.SetResourceVersion(o.GetResourceVersion())
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if retried {
+			if err := c.Get(ctx, util.ObjectNamespacedName(o), o); err != nil {
+				return errors.WithStack(err)
+			}
+
+			from = o.DeepCopyObject().(T) //nolint:forcetypeassert
+			copyStatus(desired, o)
+		}
+
+		retried = true
+		return errors.WithStack(c.Status().Patch(
+			ctx, o, client.MergeFromWithOptions(from, client.MergeFromWithOptimisticLock{}),
+		))
+	})
+
+	return errors.Wrap(client.IgnoreNotFound(err), "failed patching status")
+}
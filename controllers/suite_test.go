@@ -29,6 +29,7 @@ import (
 	. "github.com/onsi/gomega"
 	gtypes "github.com/onsi/gomega/types"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -99,9 +100,12 @@ var _ = BeforeSuite(func() {
 	recorder := metrics.NewRecorder()
 
 	err = (&MergeTargetReconciler{
-		Client:   k8sManager.GetClient(),
-		Scheme:   k8sManager.GetScheme(),
-		Recorder: recorder,
+		Client:    k8sManager.GetClient(),
+		Scheme:    k8sManager.GetScheme(),
+		Recorder:  recorder,
+		APIReader: k8sManager.GetAPIReader(),
+
+		EventRecorder: k8sManager.GetEventRecorderFor("cmmc"),
 	}).SetupWithManager(k8sManager, controller.Options{})
 	Expect(err).ToNot(HaveOccurred())
 
@@ -183,6 +187,41 @@ var _ = Describe("cmmc", func() {
 			).Should(HaveConfigMapState(ms...))
 		}
 
+		// configMap, configMapData, mergeTargetStatus and condition poll the
+		// cluster, for Eventually and Consistently.
+		configMap := func(name types.NamespacedName) func() (*corev1.ConfigMap, error) {
+			return func() (*corev1.ConfigMap, error) {
+				var cm corev1.ConfigMap
+				err := k8sClient.Get(ctx, name, &cm)
+				return &cm, err //nolint:wrapcheck
+			}
+		}
+
+		configMapData := func(name types.NamespacedName) func() (map[string]string, error) {
+			return func() (map[string]string, error) {
+				cm, err := configMap(name)()
+				return cm.Data, err
+			}
+		}
+
+		mergeTargetStatus := func(name types.NamespacedName) func() (cmmcv1beta1.MergeTargetStatus, error) {
+			return func() (cmmcv1beta1.MergeTargetStatus, error) {
+				var mt cmmcv1beta1.MergeTarget
+				err := k8sClient.Get(ctx, name, &mt)
+				return mt.Status, err //nolint:wrapcheck
+			}
+		}
+
+		condition := func(name types.NamespacedName, conditionType string) func() (*metav1.Condition, error) {
+			return func() (*metav1.Condition, error) {
+				var mt cmmcv1beta1.MergeTarget
+				if err := k8sClient.Get(ctx, name, &mt); err != nil {
+					return nil, err //nolint:wrapcheck
+				}
+				return mt.FindStatusCondition(conditionType), nil
+			}
+		}
+
 		It("should first have a source ConfigMap", func() {
 			Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
 				TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap"},
@@ -197,6 +236,8 @@ var _ = Describe("cmmc", func() {
 					Selector: selector,
 					Source:   cmmcv1beta1.MergeSourceSourceSpec{Data: "mapRoles"},
 					Target:   cmmcv1beta1.MergeSourceTargetSpec{Name: names.target.String(), Data: "mapRoles"},
+
+					AnnotateSources: true,
 				})
 				Expect(k8sClient.Create(ctx, rolesMergeSource)).Should(Succeed())
 			})
@@ -206,6 +247,8 @@ var _ = Describe("cmmc", func() {
 					Selector: selector,
 					Source:   cmmcv1beta1.MergeSourceSourceSpec{Data: "mapUsers"},
 					Target:   cmmcv1beta1.MergeSourceTargetSpec{Name: names.target.String(), Data: "mapUsers"},
+
+					AnnotateSources: true,
 				})
 				Expect(k8sClient.Create(ctx, usersMergeSource)).Should(Succeed())
 			})
@@ -235,6 +278,12 @@ var _ = Describe("cmmc", func() {
 					ManagedByAnnotation(names.target.String()),
 				)
 			})
+
+			It("should back up its revert state", func() {
+				Eventually(configMapData(stateConfigMapName(mergeTarget)), timeout, interval).Should(
+					And(HaveKey(stateKey), HaveKey(stateDigestKey)),
+				)
+			})
 		})
 
 		When("removing a key in a MergeTarget", func() {
@@ -255,6 +304,984 @@ var _ = Describe("cmmc", func() {
 			})
 		})
 
+		When("sharing the target ConfigMap with another MergeTarget", func() {
+			var (
+				accountsTarget    *cmmcv1beta1.MergeTarget
+				nextAccounts      *cmmcv1beta1.MergeTarget
+				conflictingTarget *cmmcv1beta1.MergeTarget
+			)
+
+			It("can create a MergeTarget for other keys", func() {
+				accountsTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/accounts-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: names.targetCM.String(),
+						Data:   map[string]cmmcv1beta1.MergeTargetDataSpec{"mapAccounts": {Init: "- accounts"}},
+					},
+				)
+				Expect(k8sClient.Create(ctx, accountsTarget)).Should(Succeed())
+			})
+
+			It("manages its keys next to the other MergeTarget", func() {
+				Eventually(configMapData(names.targetCM), timeout, interval).Should(HaveKeyWithValue("mapAccounts", "- accounts"))
+				assertConfigMapState(names.targetCM,
+					MapRoles(mapRoles1),
+					ManagedByAnnotation(names.target.String()+",default/accounts-target"),
+				)
+			})
+
+			It("can create a MergeTarget for keys that are already managed", func() {
+				conflictingTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/conflicting-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: names.targetCM.String(),
+						Data:   map[string]cmmcv1beta1.MergeTargetDataSpec{"mapRoles": {Init: "- conflict"}},
+					},
+				)
+				Expect(k8sClient.Create(ctx, conflictingTarget)).Should(Succeed())
+			})
+
+			It("reports the conflict, and doesn't touch the key", func() {
+				Eventually(
+					condition(util.ObjectNamespacedName(conflictingTarget), cmmcv1beta1.MergeTargetConditionTypeConflict),
+					timeout,
+					interval,
+				).Should(HaveField("Reason", "keyManagedByOtherTarget"))
+				assertConfigMapState(names.targetCM, MapRoles(mapRoles1))
+			})
+
+			It("can ask for a handover of the keys", func() {
+				nextAccounts = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/next-accounts-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: names.targetCM.String(),
+						Data:   map[string]cmmcv1beta1.MergeTargetDataSpec{"mapAccounts": {Init: "- accounts"}},
+					},
+				)
+				nextAccounts.SetAnnotations(map[string]string{
+					handoverFrom.String(): util.ObjectResourceName(accountsTarget),
+				})
+				Expect(k8sClient.Create(ctx, nextAccounts)).Should(Succeed())
+			})
+
+			It("hands over the keys once the owner agrees", func() {
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(accountsTarget), accountsTarget)).Should(Succeed())
+				accountsTarget.SetAnnotations(map[string]string{
+					handoverTo.String(): util.ObjectResourceName(nextAccounts),
+				})
+				Expect(k8sClient.Update(ctx, accountsTarget)).Should(Succeed())
+
+				Eventually(configMap(names.targetCM), timeout, interval).Should(
+					WithTransform(func(cm *corev1.ConfigMap) string { return parseKeyOwners(cm)["mapAccounts"] },
+						Equal(util.ObjectResourceName(nextAccounts))),
+				)
+			})
+
+			It("keeps the data of handed over keys when the previous owner is deleted", func() {
+				Expect(k8sClient.Delete(ctx, accountsTarget)).Should(Succeed())
+				Consistently(configMapData(names.targetCM), time.Second, interval).Should(HaveKeyWithValue("mapAccounts", "- accounts"))
+			})
+
+			It("only reverts its own keys when deleted", func() {
+				Expect(k8sClient.Delete(ctx, conflictingTarget)).Should(Succeed())
+				Expect(k8sClient.Delete(ctx, nextAccounts)).Should(Succeed())
+
+				Eventually(configMapData(names.targetCM), timeout, interval).ShouldNot(HaveKey("mapAccounts"))
+				assertConfigMapState(names.targetCM,
+					MapRoles(mapRoles1),
+					MapUsers(mapUsers1),
+					ManagedByAnnotation(names.target.String()),
+				)
+			})
+
+			It("applies the annotations, and leaves nothing to the baseline field manager", func() {
+				Eventually(configMap(names.targetCM), timeout, interval).Should(HaveField("ManagedFields", And(
+					ContainElement(And(
+						HaveField("Manager", fieldManager),
+						HaveField("Operation", metav1.ManagedFieldsOperationApply),
+					)),
+					Not(ContainElement(HaveField("Manager", baselineFieldManager))),
+				)))
+			})
+		})
+
+		When("changing the target of a MergeTarget", func() {
+			var (
+				movingTarget *cmmcv1beta1.MergeTarget
+
+				from = util.MustNamespacedName("default/move-from", "")
+				to   = util.MustNamespacedName("default/move-to", "")
+			)
+
+			It("can create a MergeTarget", func() {
+				movingTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/moving-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: from.String(),
+						Data:   map[string]cmmcv1beta1.MergeTargetDataSpec{"moved": {Init: "- moved"}},
+					},
+				)
+				Expect(k8sClient.Create(ctx, movingTarget)).Should(Succeed())
+				Eventually(configMapData(from), timeout, interval).Should(HaveKeyWithValue("moved", "- moved"))
+			})
+
+			It("knows it created the target", func() {
+				Eventually(mergeTargetStatus(util.ObjectNamespacedName(movingTarget)), timeout, interval).Should(And(
+					HaveField("NewlyCreated", cmmcv1beta1.DataNewlyCreatedStatusYes),
+					HaveField("Conditions", Not(ContainElement(
+						HaveField("Type", cmmcv1beta1.MergeTargetConditionTypeTargetDeleted),
+					))),
+				))
+			})
+
+			It("can change the target", func() {
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(movingTarget), movingTarget)).Should(Succeed())
+				movingTarget.Spec.Target = to.String()
+				Expect(k8sClient.Update(ctx, movingTarget)).Should(Succeed())
+			})
+
+			It("removes the previous target it created, and adopts the new one", func() {
+				Eventually(configMapData(to), timeout, interval).Should(HaveKeyWithValue("moved", "- moved"))
+				Eventually(func() bool {
+					_, err := configMap(from)()
+					return k8serrors.IsNotFound(err)
+				}, timeout, interval).Should(BeTrue())
+
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(movingTarget), movingTarget)).Should(Succeed())
+				Expect(movingTarget.Status.Target).Should(Equal(to.String()))
+			})
+
+			It("recreates the target when it is deleted", func() {
+				Expect(k8sClient.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metaFromName(to, nil)})).Should(Succeed())
+				Eventually(
+					condition(util.ObjectNamespacedName(movingTarget), cmmcv1beta1.MergeTargetConditionTypeTargetDeleted),
+					timeout,
+					interval,
+				).ShouldNot(BeNil())
+				Eventually(configMapData(to), timeout, interval).Should(HaveKeyWithValue("moved", "- moved"))
+			})
+
+			It("can be deleted", func() {
+				Expect(k8sClient.Delete(ctx, movingTarget)).Should(Succeed())
+			})
+		})
+
+		When("the status of a MergeTarget is lost", func() {
+			var (
+				restoredTarget *cmmcv1beta1.MergeTarget
+
+				target = util.MustNamespacedName("default/restored-target", "")
+			)
+
+			It("restores it from the state ConfigMap", func() {
+				Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+					ObjectMeta: metaFromName(target, nil),
+					Data:       map[string]string{"existing": "- existing"},
+				})).Should(Succeed())
+
+				restoredTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/restored-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: target.String(),
+						Data: map[string]cmmcv1beta1.MergeTargetDataSpec{
+							"existing": {},
+							"created":  {Init: "- created"},
+						},
+					},
+				)
+				restoredTarget.Annotations = map[string]string{acknowledgeRemoval.String(): "1"}
+				Expect(k8sClient.Create(ctx, restoredTarget)).Should(Succeed())
+
+				status := mergeTargetStatus(util.ObjectNamespacedName(restoredTarget))
+				restored := And(
+					HaveField("NewlyCreated", cmmcv1beta1.DataNewlyCreatedStatusNo),
+					HaveField("HandledRequests", HaveKeyWithValue(acknowledgeRemoval.String(), "1")),
+					HaveField("Data", HaveKeyWithValue("existing", HaveField("Init", "- existing"))),
+					HaveField("Data", HaveKeyWithValue("created", And(
+						HaveField("Init", "- created"),
+						HaveField("NewlyCreated", cmmcv1beta1.DataNewlyCreatedStatusYes),
+					))),
+				)
+				Eventually(status, timeout, interval).Should(restored)
+
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(restoredTarget), restoredTarget)).Should(Succeed())
+				restoredTarget.Status = cmmcv1beta1.MergeTargetStatus{}
+				Expect(k8sClient.Status().Update(ctx, restoredTarget)).Should(Succeed())
+
+				Eventually(status, timeout, interval).Should(restored)
+			})
+
+			It("can be deleted", func() {
+				Expect(k8sClient.Delete(ctx, restoredTarget)).Should(Succeed())
+			})
+		})
+
+		When("a MergeTarget deleting its target never managed it", func() {
+			var (
+				unmanagedTarget *cmmcv1beta1.MergeTarget
+
+				target = util.MustNamespacedName("default/unmanaged-target", "")
+			)
+
+			It("reverts the target instead of deleting it", func() {
+				Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+					ObjectMeta: metaFromName(target, nil),
+					Data:       map[string]string{"mapRoles": "- existing"},
+				})).Should(Succeed())
+
+				unmanagedTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/unmanaged-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target:         target.String(),
+						DryRun:         true,
+						DeletionPolicy: cmmcv1beta1.DeletionPolicyDelete,
+						Data:           map[string]cmmcv1beta1.MergeTargetDataSpec{"mapRoles": {}},
+					},
+				)
+				Expect(k8sClient.Create(ctx, unmanagedTarget)).Should(Succeed())
+				Eventually(
+					func() ([]string, error) {
+						var mt cmmcv1beta1.MergeTarget
+						err := k8sClient.Get(ctx, util.ObjectNamespacedName(unmanagedTarget), &mt)
+						return mt.GetFinalizers(), err //nolint:wrapcheck
+					},
+					timeout,
+					interval,
+				).ShouldNot(BeEmpty())
+
+				Expect(k8sClient.Delete(ctx, unmanagedTarget)).Should(Succeed())
+				Eventually(
+					func() bool {
+						var mt cmmcv1beta1.MergeTarget
+						return k8serrors.IsNotFound(k8sClient.Get(ctx, util.ObjectNamespacedName(unmanagedTarget), &mt))
+					},
+					timeout,
+					interval,
+				).Should(BeTrue())
+
+				Expect(configMapData(target)()).Should(HaveKeyWithValue("mapRoles", "- existing"))
+			})
+		})
+
+		When("too many entries of a key disappear at once", func() {
+			var (
+				guardedTarget *cmmcv1beta1.MergeTarget
+
+				target = util.MustNamespacedName("default/guarded-target", "")
+			)
+
+			var (
+				targetData       = configMapData(target)
+				blockedCondition = condition(
+					util.MustNamespacedName("default/guarded-target", ""), cmmcv1beta1.MergeTargetConditionTypeBlocked,
+				)
+			)
+
+			It("can create a guarded MergeTarget", func() {
+				maxRemoved := int32(1)
+				guardedTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/guarded-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target:       target.String(),
+						Data:         map[string]cmmcv1beta1.MergeTargetDataSpec{"guarded": {Init: "- a\n- b\n- c\n"}},
+						RemovalGuard: &cmmcv1beta1.MergeTargetRemovalGuard{MaxRemovedEntries: &maxRemoved},
+					},
+				)
+				Expect(k8sClient.Create(ctx, guardedTarget)).Should(Succeed())
+				Eventually(targetData, timeout, interval).Should(HaveKeyWithValue("guarded", "- a\n- b\n- c\n"))
+			})
+
+			It("holds the previous value", func() {
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(guardedTarget), guardedTarget)).Should(Succeed())
+				guardedTarget.Spec.Data["guarded"] = cmmcv1beta1.MergeTargetDataSpec{Init: "- a\n"}
+				Expect(k8sClient.Update(ctx, guardedTarget)).Should(Succeed())
+
+				Eventually(blockedCondition, timeout, interval).Should(
+					And(Not(BeNil()), HaveField("Message", ContainSubstring("guarded: 2 of 3 entries"))),
+				)
+				Expect(targetData()).Should(HaveKeyWithValue("guarded", "- a\n- b\n- c\n"))
+			})
+
+			It("removes the entries once acknowledged", func() {
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(guardedTarget), guardedTarget)).Should(Succeed())
+				guardedTarget.Annotations = map[string]string{acknowledgeRemoval.String(): "1"}
+				Expect(k8sClient.Update(ctx, guardedTarget)).Should(Succeed())
+
+				Eventually(targetData, timeout, interval).Should(HaveKeyWithValue("guarded", "- a\n"))
+				Eventually(blockedCondition, timeout, interval).Should(BeNil())
+			})
+
+			It("can be deleted", func() {
+				Expect(k8sClient.Delete(ctx, guardedTarget)).Should(Succeed())
+			})
+		})
+
+		When("a source of a key isolating sources is invalid", func() {
+			var (
+				isolatingTarget *cmmcv1beta1.MergeTarget
+				isolatedSource  *cmmcv1beta1.MergeSource
+
+				target   = util.MustNamespacedName("default/isolating-target", "")
+				selector = map[string]string{"cmmc-test": "isolated"}
+				good     = util.MustNamespacedName("default/isolated-good", "")
+				bad      = util.MustNamespacedName("default/isolated-bad", "")
+				dryRun   = util.MustNamespacedName("default/isolated-dry-run", "")
+			)
+
+			It("leaves its contribution out", func() {
+				isolatingTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/isolating-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: target.String(),
+						Data: map[string]cmmcv1beta1.MergeTargetDataSpec{
+							"isolated": {
+								ItemSchema:     `{"type":"object","required":["rolearn"]}`,
+								InvalidSources: cmmcv1beta1.InvalidSourcePolicyExclude,
+							},
+						},
+					},
+				)
+				Expect(k8sClient.Create(ctx, isolatingTarget)).Should(Succeed())
+
+				isolatedSource = cmmcv1beta1.NewMergeSource(
+					util.MustNamespacedName("default/isolated-source", ""),
+					cmmcv1beta1.MergeSourceSpec{
+						Selector:        selector,
+						Source:          cmmcv1beta1.MergeSourceSourceSpec{Data: "isolated"},
+						Target:          cmmcv1beta1.MergeSourceTargetSpec{Name: util.ObjectResourceName(isolatingTarget), Data: "isolated"},
+						AnnotateSources: true,
+					},
+				)
+				Expect(k8sClient.Create(ctx, isolatedSource)).Should(Succeed())
+				Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+					ObjectMeta: metaFromName(good, selector),
+					Data:       map[string]string{"isolated": "- rolearn: good\n"},
+				})).Should(Succeed())
+				Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+					ObjectMeta: metaFromName(bad, selector),
+					Data:       map[string]string{"isolated": "- username: bad\n"},
+				})).Should(Succeed())
+
+				Eventually(configMapData(target), timeout, interval).Should(HaveKeyWithValue("isolated", "- rolearn: good\n"))
+				Eventually(condition(util.ObjectNamespacedName(isolatingTarget), "cmmc/Validation"), timeout, interval).Should(
+					HaveField("Message", ContainSubstring(bad.String())),
+				)
+			})
+
+			It("reports the failing entry of the source", func() {
+				var mt cmmcv1beta1.MergeTarget
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(isolatingTarget), &mt)).Should(Succeed())
+				Expect(mt.Status.ValidationErrors).Should(ConsistOf(cmmcv1beta1.MergeTargetValidationError{
+					Key:         "isolated",
+					MergeSource: util.ObjectNamespacedName(isolatedSource).String(),
+					ConfigMap:   bad.String(),
+					Pointer:     "/0",
+					Rule:        "required",
+					Message:     "rolearn is required",
+				}))
+			})
+
+			It("reports the outcome on the source ConfigMaps", func() {
+				Eventually(configMap(bad), timeout, interval).Should(HaveField("Annotations", HaveKeyWithValue(
+					"config.cmmc.k8s.cash.app/contribution-status", "default/isolating-target/isolated=rejected",
+				)))
+				Eventually(configMap(good), timeout, interval).Should(HaveField("Annotations", HaveKeyWithValue(
+					"config.cmmc.k8s.cash.app/contribution-status", "default/isolating-target/isolated=accepted",
+				)))
+				Eventually(func() ([]string, error) {
+					var events corev1.EventList
+					err := k8sClient.List(ctx, &events, client.InNamespace(bad.Namespace))
+
+					var reasons []string
+					for _, e := range events.Items {
+						if e.InvolvedObject.Name == bad.Name {
+							reasons = append(reasons, e.Reason)
+						}
+					}
+					return reasons, err //nolint:wrapcheck
+				}, timeout, interval).Should(ContainElement("ContributionRejected"))
+			})
+
+			It("validates dry-run sources without merging them", func() {
+				meta := metaFromName(dryRun, selector)
+				meta.Annotations = map[string]string{"config.cmmc.k8s.cash.app/dry-run": "true"}
+				Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+					ObjectMeta: meta,
+					Data:       map[string]string{"isolated": "- rolearn: dry\n"},
+				})).Should(Succeed())
+
+				Eventually(configMap(dryRun), timeout, interval).Should(HaveField("Annotations", HaveKeyWithValue(
+					"config.cmmc.k8s.cash.app/contribution-status", "default/isolating-target/isolated=dry-run-accepted",
+				)))
+				Consistently(configMapData(target), time.Second, interval).Should(HaveKeyWithValue("isolated", "- rolearn: good\n"))
+			})
+
+			It("validates dry-run sources again when they change", func() {
+				cm, err := configMap(dryRun)()
+				Expect(err).ShouldNot(HaveOccurred())
+				cm.Data["isolated"] = "- username: dry\n"
+				Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+
+				Eventually(configMap(dryRun), timeout, interval).Should(HaveField("Annotations", HaveKeyWithValue(
+					"config.cmmc.k8s.cash.app/contribution-status", "default/isolating-target/isolated=dry-run-rejected",
+				)))
+				Consistently(configMapData(target), time.Second, interval).Should(HaveKeyWithValue("isolated", "- rolearn: good\n"))
+			})
+
+			It("can be deleted", func() {
+				Expect(k8sClient.Delete(ctx, isolatedSource)).Should(Succeed())
+				Expect(k8sClient.Delete(ctx, isolatingTarget)).Should(Succeed())
+				Expect(k8sClient.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metaFromName(good, nil)})).Should(Succeed())
+				Expect(k8sClient.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metaFromName(bad, nil)})).Should(Succeed())
+				Expect(k8sClient.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metaFromName(dryRun, nil)})).Should(Succeed())
+			})
+		})
+
+		When("a source of a key keeping the last known good contributions turns invalid", func() {
+			var (
+				lastKnownGoodTarget *cmmcv1beta1.MergeTarget
+				lastKnownGoodSource *cmmcv1beta1.MergeSource
+
+				target   = util.MustNamespacedName("default/last-known-good-target", "")
+				selector = map[string]string{"cmmc-test": "last-known-good"}
+				source   = util.MustNamespacedName("default/last-known-good", "")
+			)
+
+			It("keeps merging its last valid contribution", func() {
+				lastKnownGoodTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/last-known-good-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: target.String(),
+						Data: map[string]cmmcv1beta1.MergeTargetDataSpec{
+							"roles": {
+								ItemSchema:     `{"type":"object","required":["rolearn"]}`,
+								InvalidSources: cmmcv1beta1.InvalidSourcePolicyLastKnownGood,
+							},
+						},
+					},
+				)
+				Expect(k8sClient.Create(ctx, lastKnownGoodTarget)).Should(Succeed())
+
+				lastKnownGoodSource = cmmcv1beta1.NewMergeSource(
+					util.MustNamespacedName("default/last-known-good-source", ""),
+					cmmcv1beta1.MergeSourceSpec{
+						Selector: selector,
+						Source:   cmmcv1beta1.MergeSourceSourceSpec{Data: "roles"},
+						Target: cmmcv1beta1.MergeSourceTargetSpec{
+							Name: util.ObjectResourceName(lastKnownGoodTarget), Data: "roles",
+						},
+					},
+				)
+				Expect(k8sClient.Create(ctx, lastKnownGoodSource)).Should(Succeed())
+				Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+					ObjectMeta: metaFromName(source, selector),
+					Data:       map[string]string{"roles": "- rolearn: good\n"},
+				})).Should(Succeed())
+				Eventually(configMapData(target), timeout, interval).Should(HaveKeyWithValue("roles", "- rolearn: good\n"))
+
+				cm, err := configMap(source)()
+				Expect(err).ShouldNot(HaveOccurred())
+				cm.Data["roles"] = "- username: bad\n"
+				Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+
+				Eventually(condition(util.ObjectNamespacedName(lastKnownGoodTarget), "cmmc/Validation"), timeout, interval).Should(
+					HaveField("Message", ContainSubstring(source.String())),
+				)
+				Consistently(configMapData(target), time.Second, interval).Should(HaveKeyWithValue("roles", "- rolearn: good\n"))
+			})
+
+			It("keeps it next to the MergeTarget, and only its digest in the status", func() {
+				digest := util.Digest("- rolearn: good\n")
+				Expect(mergeTargetStatus(util.ObjectNamespacedName(lastKnownGoodTarget))()).Should(HaveField(
+					"Data", HaveKeyWithValue("roles", HaveField("LastKnownGoodDigests", HaveKeyWithValue(source.String(), digest))),
+				))
+				Expect(configMapData(util.MustNamespacedName("default/last-known-good-target-cmmc-last-known-good", ""))()).Should(
+					Equal(map[string]string{digest: "- rolearn: good\n"}),
+				)
+			})
+
+			It("can be deleted", func() {
+				Expect(k8sClient.Delete(ctx, lastKnownGoodSource)).Should(Succeed())
+				Expect(k8sClient.Delete(ctx, lastKnownGoodTarget)).Should(Succeed())
+				Expect(k8sClient.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metaFromName(source, nil)})).Should(Succeed())
+			})
+		})
+
+		When("a merged value is missing a required entry", func() {
+			var (
+				requiringTarget *cmmcv1beta1.MergeTarget
+
+				target = util.MustNamespacedName("default/requiring-target", "")
+			)
+
+			It("refuses to write it", func() {
+				requiringTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/requiring-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: target.String(),
+						Data: map[string]cmmcv1beta1.MergeTargetDataSpec{
+							"required": {Init: "- rolearn: node\n- rolearn: team\n", RequiredEntries: []string{"rolearn: node"}},
+						},
+					},
+				)
+				Expect(k8sClient.Create(ctx, requiringTarget)).Should(Succeed())
+				Eventually(configMapData(target), timeout, interval).Should(
+					HaveKeyWithValue("required", "- rolearn: node\n- rolearn: team\n"),
+				)
+
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(requiringTarget), requiringTarget)).Should(Succeed())
+				requiringTarget.Spec.Data["required"] = cmmcv1beta1.MergeTargetDataSpec{
+					Init: "- rolearn: team\n", RequiredEntries: []string{"rolearn: node"},
+				}
+				Expect(k8sClient.Update(ctx, requiringTarget)).Should(Succeed())
+
+				Eventually(
+					condition(util.ObjectNamespacedName(requiringTarget), cmmcv1beta1.MergeTargetConditionTypeRequiredEntryMissing),
+					timeout,
+					interval,
+				).Should(And(Not(BeNil()), HaveField("Message", ContainSubstring("required: rolearn: node"))))
+
+				Expect(configMapData(target)()).Should(HaveKeyWithValue("required", "- rolearn: node\n- rolearn: team\n"))
+			})
+
+			It("can be deleted", func() {
+				Expect(k8sClient.Delete(ctx, requiringTarget)).Should(Succeed())
+			})
+		})
+
+		When("the data of the target doesn't validate against the schema of the MergeTarget", func() {
+			var (
+				schemaTarget *cmmcv1beta1.MergeTarget
+
+				target = util.MustNamespacedName("default/schema-target", "")
+			)
+
+			It("doesn't write any of it", func() {
+				schemaTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/schema-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: target.String(),
+						Schema: `{"type":"object","properties":{"list":{"type":"array","maxItems":1},"text":{"type":"string"}}}`,
+						Data: map[string]cmmcv1beta1.MergeTargetDataSpec{
+							"list": {Init: "- a\n"},
+							"text": {Init: "- a\n", Format: cmmcv1beta1.DataFormatText},
+						},
+					},
+				)
+				Expect(k8sClient.Create(ctx, schemaTarget)).Should(Succeed())
+				Eventually(configMapData(target), timeout, interval).Should(HaveKeyWithValue("list", "- a\n"))
+
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(schemaTarget), schemaTarget)).Should(Succeed())
+				schemaTarget.Spec.Data["list"] = cmmcv1beta1.MergeTargetDataSpec{Init: "- a\n- b\n"}
+				schemaTarget.Spec.Data["text"] = cmmcv1beta1.MergeTargetDataSpec{Init: "- b\n", Format: cmmcv1beta1.DataFormatText}
+				Expect(k8sClient.Update(ctx, schemaTarget)).Should(Succeed())
+
+				Eventually(
+					condition(util.ObjectNamespacedName(schemaTarget), cmmcv1beta1.MergeTargetConditionTypeSchemaViolated),
+					timeout,
+					interval,
+				).ShouldNot(BeNil())
+
+				Expect(configMapData(target)()).Should(And(HaveKeyWithValue("list", "- a\n"), HaveKeyWithValue("text", "- a\n")))
+			})
+
+			It("can be deleted", func() {
+				Expect(k8sClient.Delete(ctx, schemaTarget)).Should(Succeed())
+			})
+		})
+
+		When("the data of the target breaks an invariant of the MergeTarget", func() {
+			var (
+				invariantTarget *cmmcv1beta1.MergeTarget
+
+				target = util.MustNamespacedName("default/invariant-target", "")
+			)
+
+			It("doesn't write any of it", func() {
+				invariantTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/invariant-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: target.String(),
+						Invariants: []cmmcv1beta1.MergeTargetInvariant{{
+							Name: "usernames",
+							Unique: []cmmcv1beta1.MergeTargetDataPath{
+								{Key: "mapRoles", Path: "/*/username"},
+								{Key: "mapUsers", Path: "/*/username"},
+							},
+						}},
+						Data: map[string]cmmcv1beta1.MergeTargetDataSpec{
+							"mapRoles": {Init: "- username: a\n"},
+							"mapUsers": {Init: "- username: b\n"},
+						},
+					},
+				)
+				Expect(k8sClient.Create(ctx, invariantTarget)).Should(Succeed())
+				Eventually(configMapData(target), timeout, interval).Should(HaveKeyWithValue("mapUsers", "- username: b\n"))
+
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(invariantTarget), invariantTarget)).Should(Succeed())
+				invariantTarget.Spec.Data["mapUsers"] = cmmcv1beta1.MergeTargetDataSpec{Init: "- username: a\n"}
+				Expect(k8sClient.Update(ctx, invariantTarget)).Should(Succeed())
+
+				Eventually(
+					condition(util.ObjectNamespacedName(invariantTarget), cmmcv1beta1.MergeTargetConditionTypeSchemaViolated),
+					timeout,
+					interval,
+				).Should(And(Not(BeNil()), HaveField("Message", ContainSubstring("usernames"))))
+
+				Expect(configMapData(target)()).Should(HaveKeyWithValue("mapUsers", "- username: b\n"))
+			})
+
+			It("can be deleted", func() {
+				Expect(k8sClient.Delete(ctx, invariantTarget)).Should(Succeed())
+			})
+		})
+
+		When("a key doesn't validate against a schema that isn't enforced", func() {
+			var (
+				warningTarget *cmmcv1beta1.MergeTarget
+
+				target = util.MustNamespacedName("default/warning-target", "")
+			)
+
+			It("writes it, and reports the violations", func() {
+				warningTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/warning-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: target.String(),
+						Data: map[string]cmmcv1beta1.MergeTargetDataSpec{
+							"warned": {
+								Init:        "- username: nobody\n",
+								JSONSchema:  `{"type":"array","items":{"required":["rolearn"]}}`,
+								Enforcement: cmmcv1beta1.SchemaEnforcementWarn,
+							},
+							"audited": {
+								Init:            "- username: nobody\n",
+								CandidateSchema: `{"type":"array","items":{"required":["rolearn"]}}`,
+								Enforcement:     cmmcv1beta1.SchemaEnforcementAudit,
+							},
+						},
+					},
+				)
+				Expect(k8sClient.Create(ctx, warningTarget)).Should(Succeed())
+
+				Eventually(configMapData(target), timeout, interval).Should(And(
+					HaveKeyWithValue("warned", "- username: nobody\n"),
+					HaveKeyWithValue("audited", "- username: nobody\n"),
+				))
+				Eventually(
+					condition(util.ObjectNamespacedName(warningTarget), cmmcv1beta1.MergeTargetConditionTypeSchemaWarning),
+					timeout,
+					interval,
+				).Should(And(
+					Not(BeNil()),
+					HaveField("Message", ContainSubstring("warned: ")),
+					HaveField("Message", ContainSubstring("audited: candidate schema: ")),
+				))
+			})
+
+			It("can be deleted", func() {
+				Expect(k8sClient.Delete(ctx, warningTarget)).Should(Succeed())
+			})
+		})
+
+		When("a key is validated with another schema engine", func() {
+			var (
+				engineTarget *cmmcv1beta1.MergeTarget
+
+				target = util.MustNamespacedName("default/engine-target", "")
+			)
+
+			It("enforces the keywords and formats of the engine", func() {
+				engineTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/engine-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: target.String(),
+						Data: map[string]cmmcv1beta1.MergeTargetDataSpec{
+							"mapRoles": {
+								Init: "- rolearn: arn:aws:iam::111122223333:role/a\n",
+								JSONSchema: `{"type":"array","items":{"$ref":"#/$defs/role"},"$defs":{"role":{` +
+									`"properties":{"rolearn":{"type":"string","format":"aws-arn"}},"unevaluatedProperties":false}}}`,
+								SchemaEngine: cmmcv1beta1.SchemaEngine202012,
+							},
+						},
+					},
+				)
+				Expect(k8sClient.Create(ctx, engineTarget)).Should(Succeed())
+				Eventually(configMapData(target), timeout, interval).Should(
+					HaveKeyWithValue("mapRoles", "- rolearn: arn:aws:iam::111122223333:role/a\n"),
+				)
+
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(engineTarget), engineTarget)).Should(Succeed())
+				spec := engineTarget.Spec.Data["mapRoles"]
+				spec.Init = "- rolearn: role/a\n"
+				engineTarget.Spec.Data["mapRoles"] = spec
+				Expect(k8sClient.Update(ctx, engineTarget)).Should(Succeed())
+
+				Eventually(mergeTargetStatus(util.ObjectNamespacedName(engineTarget)), timeout, interval).Should(
+					HaveField("ValidationErrors", ContainElement(And(
+						HaveField("Pointer", "/0/rolearn"),
+						HaveField("Rule", "format"),
+					))),
+				)
+
+				Expect(configMapData(target)()).Should(HaveKeyWithValue("mapRoles", "- rolearn: arn:aws:iam::111122223333:role/a\n"))
+			})
+
+			It("can be deleted", func() {
+				Expect(k8sClient.Delete(ctx, engineTarget)).Should(Succeed())
+			})
+		})
+
+		When("the data of a MergeTarget changes", func() {
+			var (
+				revisedTarget *cmmcv1beta1.MergeTarget
+
+				target = util.MustNamespacedName("default/revised-target", "")
+			)
+
+			targetData := configMapData(target)
+
+			currentRevision := func() (int64, error) {
+				status, err := mergeTargetStatus(util.ObjectNamespacedName(revisedTarget))()
+				return status.CurrentRevision, err
+			}
+
+			updateSpec := func(update func(*cmmcv1beta1.MergeTargetSpec)) {
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(revisedTarget), revisedTarget)).Should(Succeed())
+				update(&revisedTarget.Spec)
+				Expect(k8sClient.Update(ctx, revisedTarget)).Should(Succeed())
+			}
+
+			It("records a revision of the written data", func() {
+				revisedTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/revised-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: target.String(),
+						Data:   map[string]cmmcv1beta1.MergeTargetDataSpec{"revised": {Init: "- first"}},
+					},
+				)
+				Expect(k8sClient.Create(ctx, revisedTarget)).Should(Succeed())
+				Eventually(currentRevision, timeout, interval).Should(BeEquivalentTo(1))
+			})
+
+			It("records a new revision when the data changes", func() {
+				updateSpec(func(spec *cmmcv1beta1.MergeTargetSpec) {
+					spec.Data["revised"] = cmmcv1beta1.MergeTargetDataSpec{Init: "- second"}
+				})
+				Eventually(currentRevision, timeout, interval).Should(BeEquivalentTo(2))
+				Expect(targetData()).Should(HaveKeyWithValue("revised", "- second"))
+
+				var revisions appsv1.ControllerRevisionList
+				Expect(k8sClient.List(ctx, &revisions, client.InNamespace("default"),
+					client.MatchingLabels{revisionOfLabel: string(revisedTarget.UID)})).Should(Succeed())
+				Expect(revisions.Items).Should(HaveLen(2))
+			})
+
+			It("rolls back to a previous revision until rollbackTo is cleared", func() {
+				first := int64(1)
+				updateSpec(func(spec *cmmcv1beta1.MergeTargetSpec) { spec.RollbackTo = &first })
+				Eventually(targetData, timeout, interval).Should(HaveKeyWithValue("revised", "- first"))
+
+				updateSpec(func(spec *cmmcv1beta1.MergeTargetSpec) { spec.RollbackTo = nil })
+				Eventually(targetData, timeout, interval).Should(HaveKeyWithValue("revised", "- second"))
+			})
+
+			It("can be deleted", func() {
+				Expect(k8sClient.Delete(ctx, revisedTarget)).Should(Succeed())
+			})
+		})
+
+		When("a MergeTarget is in dry-run mode, or suspended", func() {
+			var (
+				dryRunTarget *cmmcv1beta1.MergeTarget
+
+				target = util.MustNamespacedName("default/dry-run-target", "")
+				shadow = util.MustNamespacedName("default/dry-running-target-cmmc-dry-run", "")
+			)
+
+			It("writes the would-be data to the dry-run ConfigMap only", func() {
+				dryRunTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/dry-running-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target: target.String(),
+						Data:   map[string]cmmcv1beta1.MergeTargetDataSpec{"dry": {Init: "- dry"}},
+						DryRun: true,
+					},
+				)
+				Expect(k8sClient.Create(ctx, dryRunTarget)).Should(Succeed())
+
+				Eventually(configMap(shadow), timeout, interval).Should(And(
+					HaveField("Data", HaveKeyWithValue("dry", "- dry")),
+					HaveField("Data", HaveKeyWithValue(dryRunDiffKey, "--- dry\n+++ dry\n+- dry\n")),
+				))
+				Consistently(func() bool {
+					_, err := configMap(target)()
+					return k8serrors.IsNotFound(err)
+				}, "1s", interval).Should(BeTrue())
+			})
+
+			It("writes the target, and deletes the dry-run ConfigMap, once it is no longer in dry-run mode", func() {
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(dryRunTarget), dryRunTarget)).Should(Succeed())
+				dryRunTarget.Spec.DryRun = false
+				Expect(k8sClient.Update(ctx, dryRunTarget)).Should(Succeed())
+
+				Eventually(configMap(target), timeout, interval).Should(HaveField("Data", HaveKeyWithValue("dry", "- dry")))
+				Eventually(func() bool {
+					_, err := configMap(shadow)()
+					return k8serrors.IsNotFound(err)
+				}, timeout, interval).Should(BeTrue())
+			})
+
+			It("leaves the target alone while suspended", func() {
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(dryRunTarget), dryRunTarget)).Should(Succeed())
+				dryRunTarget.Spec.Suspend = true
+				dryRunTarget.Spec.Data = map[string]cmmcv1beta1.MergeTargetDataSpec{"dry": {Init: "- changed"}}
+				Expect(k8sClient.Update(ctx, dryRunTarget)).Should(Succeed())
+
+				Consistently(configMap(target), "1s", interval).Should(HaveField("Data", HaveKeyWithValue("dry", "- dry")))
+			})
+
+			It("can be deleted", func() {
+				Expect(k8sClient.Delete(ctx, dryRunTarget)).Should(Succeed())
+			})
+		})
+
+		When("one of the MergeSources of a key is suspended", func() {
+			var (
+				suspendingTarget *cmmcv1beta1.MergeTarget
+				mergeSources     []*cmmcv1beta1.MergeSource
+
+				target  = util.MustNamespacedName("default/suspending-target", "")
+				sources = []types.NamespacedName{
+					util.MustNamespacedName("default/suspended-a", ""),
+					util.MustNamespacedName("default/suspended-b", ""),
+				}
+			)
+
+			targetData := configMapData(target)
+
+			setSource := func(name types.NamespacedName, value string) {
+				var cm corev1.ConfigMap
+				Expect(k8sClient.Get(ctx, name, &cm)).Should(Succeed())
+				cm.Data["frozen"] = value
+				Expect(k8sClient.Update(ctx, &cm)).Should(Succeed())
+			}
+
+			It("merges all of them", func() {
+				suspendingTarget = cmmcv1beta1.NewMergeTarget(target, cmmcv1beta1.MergeTargetSpec{
+					Target: target.String(),
+					Data:   map[string]cmmcv1beta1.MergeTargetDataSpec{"frozen": {}},
+				})
+				Expect(k8sClient.Create(ctx, suspendingTarget)).Should(Succeed())
+
+				for i, name := range sources {
+					selector := map[string]string{"cmmc-test": name.Name}
+					ms := cmmcv1beta1.NewMergeSource(name, cmmcv1beta1.MergeSourceSpec{
+						Selector: selector,
+						Source:   cmmcv1beta1.MergeSourceSourceSpec{Data: "frozen"},
+						Target:   cmmcv1beta1.MergeSourceTargetSpec{Name: target.String(), Data: "frozen"},
+					})
+					Expect(k8sClient.Create(ctx, ms)).Should(Succeed())
+					mergeSources = append(mergeSources, ms)
+
+					Expect(k8sClient.Create(ctx, &corev1.ConfigMap{
+						ObjectMeta: metaFromName(name, selector),
+						Data:       map[string]string{"frozen": fmt.Sprintf("- %d\n", i)},
+					})).Should(Succeed())
+				}
+
+				Eventually(targetData, timeout, interval).Should(HaveKeyWithValue("frozen", "- 0\n- 1\n"))
+			})
+
+			It("freezes only the contribution of the suspended one", func() {
+				Expect(k8sClient.Get(ctx, sources[0], mergeSources[0])).Should(Succeed())
+				mergeSources[0].Spec.Suspend = true
+				Expect(k8sClient.Update(ctx, mergeSources[0])).Should(Succeed())
+				Eventually(mergeTargetStatus(target), timeout, interval).Should(
+					HaveField("SuspendedSources", HaveKey(sources[0].String())),
+				)
+
+				setSource(sources[0], "- 0 changed\n")
+				setSource(sources[1], "- 1 changed\n")
+				Eventually(targetData, timeout, interval).Should(HaveKeyWithValue("frozen", "- 0\n- 1 changed\n"))
+				Consistently(targetData, "1s", interval).Should(HaveKeyWithValue("frozen", "- 0\n- 1 changed\n"))
+				Eventually(mergeTargetStatus(target), timeout, interval).Should(HaveField("Contributions", And(
+					HaveKeyWithValue("frozen/"+sources[0].String(), "blocked"),
+					HaveKeyWithValue("frozen/"+sources[1].String(), "accepted"),
+				)))
+			})
+
+			It("merges its current contribution once resumed", func() {
+				Expect(k8sClient.Get(ctx, sources[0], mergeSources[0])).Should(Succeed())
+				mergeSources[0].Spec.Suspend = false
+				Expect(k8sClient.Update(ctx, mergeSources[0])).Should(Succeed())
+				Eventually(targetData, timeout, interval).Should(HaveKeyWithValue("frozen", "- 0 changed\n- 1 changed\n"))
+			})
+
+			It("can be deleted", func() {
+				for _, ms := range mergeSources {
+					Expect(k8sClient.Delete(ctx, ms)).Should(Succeed())
+				}
+				Expect(k8sClient.Delete(ctx, suspendingTarget)).Should(Succeed())
+			})
+		})
+
+		When("someone else modifies a managed key", func() {
+			var (
+				driftingTarget *cmmcv1beta1.MergeTarget
+
+				target = util.MustNamespacedName("default/drift-target", "")
+			)
+
+			var (
+				targetData       = configMapData(target)
+				driftedCondition = condition(
+					util.MustNamespacedName("default/drifting-target", ""), cmmcv1beta1.MergeTargetConditionTypeDrifted,
+				)
+			)
+
+			It("can create a MergeTarget preserving drift", func() {
+				driftingTarget = cmmcv1beta1.NewMergeTarget(
+					util.MustNamespacedName("default/drifting-target", ""),
+					cmmcv1beta1.MergeTargetSpec{
+						Target:      target.String(),
+						Data:        map[string]cmmcv1beta1.MergeTargetDataSpec{"drifting": {Init: "- managed"}},
+						DriftPolicy: cmmcv1beta1.DriftPolicyPreserve,
+					},
+				)
+				Expect(k8sClient.Create(ctx, driftingTarget)).Should(Succeed())
+				Eventually(targetData, timeout, interval).Should(HaveKeyWithValue("drifting", "- managed"))
+				Eventually(mergeTargetStatus(util.ObjectNamespacedName(driftingTarget)), timeout, interval).Should(
+					HaveField("Data", HaveKeyWithValue("drifting", HaveField("AppliedDigest", Not(BeEmpty())))),
+				)
+			})
+
+			It("reports and preserves the modification", func() {
+				var cm corev1.ConfigMap
+				Expect(k8sClient.Get(ctx, target, &cm)).Should(Succeed())
+				cm.Data["drifting"] = "- edited"
+				Expect(k8sClient.Update(ctx, &cm, client.FieldOwner("kubectl-edit"))).Should(Succeed())
+
+				Eventually(driftedCondition, timeout, interval).Should(
+					And(Not(BeNil()), HaveField("Reason", "driftPreserved"), HaveField("Message", ContainSubstring("kubectl-edit"))),
+				)
+				Consistently(targetData, "1s", interval).Should(HaveKeyWithValue("drifting", "- edited"))
+			})
+
+			It("reverts the modification once acknowledged", func() {
+				Expect(k8sClient.Get(ctx, util.ObjectNamespacedName(driftingTarget), driftingTarget)).Should(Succeed())
+				driftingTarget.Annotations = map[string]string{acknowledgeDrift.String(): "1"}
+				Expect(k8sClient.Update(ctx, driftingTarget)).Should(Succeed())
+
+				Eventually(targetData, timeout, interval).Should(HaveKeyWithValue("drifting", "- managed"))
+				Eventually(driftedCondition, timeout, interval).Should(BeNil())
+			})
+
+			It("can be deleted", func() {
+				Expect(k8sClient.Delete(ctx, driftingTarget)).Should(Succeed())
+			})
+		})
+
 		Context("cleanup", func() {
 			When("removing roles MergeSource", func() {
 				It("can be deleted", func() {
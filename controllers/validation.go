@@ -0,0 +1,71 @@
+/*
+Copyright 2021 Square, Inc
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controllers
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
+)
+
+// reportSchemaWarnings reports the keys that don't validate without being
+// blocked (with the warn or audit enforcement): in the cmmc/SchemaWarning
+// condition, with a SchemaViolation event whenever they change, and in the
+// cmmc_resource_schema_violations metric (which only keeps the current keys).
+func (r *MergeTargetReconciler) reportSchemaWarnings(mt *MergeTarget, warnings []cmmcv1beta1.SchemaWarning) {
+	var msgs []string
+	r.Recorder.ForgetSchemaViolations(mt)
+	for _, w := range warnings {
+		r.Recorder.RecordSchemaViolations(mt, w.Key, string(w.Enforcement), w.Violations)
+		msgs = append(msgs, w.Errors...)
+	}
+
+	if len(msgs) == 0 {
+		mt.RemoveStatusCondition(cmmcv1beta1.MergeTargetConditionTypeSchemaWarning)
+		return
+	}
+
+	sort.Strings(msgs)
+	condition := cmmcv1beta1.MergeTargetConditionSchemaWarning(msgs)
+	if previous := mt.FindStatusCondition(condition.Type); previous == nil || previous.Message != condition.Message {
+		r.EventRecorder.Eventf(mt, corev1.EventTypeWarning, "SchemaViolation", "%s", condition.Message)
+	}
+
+	mt.SetStatusCondition(condition)
+}
+
+// reportSchemaViolation reports whether the data of the target ConfigMap
+// validates against the schema of the MergeTarget: in the cmmc/SchemaViolated
+// condition, with a SchemaViolated event whenever the violations change.
+//
+// It returns false if the data doesn't validate, in which case none of it
+// should be written.
+func (r *MergeTargetReconciler) reportSchemaViolation(mt *MergeTarget, err error) bool {
+	if err == nil {
+		mt.RemoveStatusCondition(cmmcv1beta1.MergeTargetConditionTypeSchemaViolated)
+		return true
+	}
+
+	condition := cmmcv1beta1.MergeTargetConditionSchemaViolated(err)
+	if previous := mt.FindStatusCondition(condition.Type); previous == nil || previous.Message != condition.Message {
+		r.EventRecorder.Eventf(mt, corev1.EventTypeWarning, "SchemaViolated", "%s", condition.Message)
+	}
+
+	mt.SetStatusCondition(condition)
+	return false
+}
@@ -19,6 +19,7 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -35,7 +36,9 @@ import (
 
 	cmmcv1beta1 "github.com/cashapp/cmmc/api/v1beta1"
 	"github.com/cashapp/cmmc/controllers"
+	"github.com/cashapp/cmmc/util/cache"
 	"github.com/cashapp/cmmc/util/metrics"
+	"github.com/cashapp/cmmc/util/validator"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -58,6 +61,11 @@ func main() { //nolint:funlen
 		probeAddr                          string
 		mergeTargetMaxConcurrentReconciles int
 		mergeSourceMaxConcurrentReconciles int
+		cacheNamespaces                    string
+		cacheConfigMapSelector             string
+		mergeSourceDigestOnly              bool
+		schemaCacheSize                    int
+		targetResyncPeriod                 time.Duration
 		displayHelp                        bool
 		opts                               = zap.Options{Development: true}
 	)
@@ -65,6 +73,17 @@ func main() { //nolint:funlen
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.IntVar(&mergeTargetMaxConcurrentReconciles, "merge-target-max-concurrent-reconciles", 1, "MergeTargetController - MaxConcurrentReconciles")
 	flag.IntVar(&mergeSourceMaxConcurrentReconciles, "merge-source-max-concurrent-reconciles", 1, "MergeSourceController - MaxConcurrentReconciles")
+	flag.StringVar(&cacheNamespaces, "namespaces", "",
+		"Comma separated list of namespaces to watch/cache. Defaults to all namespaces.")
+	flag.StringVar(&cacheConfigMapSelector, "configmap-selector", "",
+		"Label selector restricting which ConfigMaps are watched/cached. "+
+			"Source ConfigMaps must match it, target ConfigMaps are read directly from the API server.")
+	flag.BoolVar(&mergeSourceDigestOnly, "merge-source-digest-only", false,
+		"Only keep the digest of the accumulated data in the MergeSource status, not the data itself.")
+	flag.DurationVar(&targetResyncPeriod, "target-resync-period", time.Minute,
+		"How often MergeTargets are reconciled when target ConfigMaps aren't watched (with -namespaces or -configmap-selector).")
+	flag.IntVar(&schemaCacheSize, "schema-cache-size", validator.DefaultCacheSize,
+		"Number of compiled JSON schemas kept in memory.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -79,9 +98,21 @@ func main() { //nolint:funlen
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	cacheOpts, err := cache.NewOptions(cacheNamespaces, cacheConfigMapSelector)
+	if err != nil {
+		setupLog.Error(err, "invalid cache options")
+		os.Exit(1)
+	}
+
+	// only the revisions of MergeTargets are read, not all of the cluster's.
+	cacheOpts.RevisionSelector = controllers.RevisionSelector()
+
 	recorder := initRecorder()
+	validator.SetCache(validator.NewSchemaCache(schemaCacheSize, recorder.RecordSchemaCacheLookup))
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
+		NewCache:               cacheOpts.NewCacheFunc(),
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443, //nolint: gomnd
 		HealthProbeBindAddress: probeAddr,
@@ -94,9 +125,10 @@ func main() { //nolint:funlen
 	}
 
 	if err = (&controllers.MergeSourceReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: recorder,
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		Recorder:   recorder,
+		DigestOnly: mergeSourceDigestOnly,
 	}).SetupWithManager(mgr, controller.Options{
 		MaxConcurrentReconciles: mergeSourceMaxConcurrentReconciles,
 	}); err != nil {
@@ -104,10 +136,23 @@ func main() { //nolint:funlen
 		os.Exit(1)
 	}
 
+	// target ConfigMaps outside of the cache aren't watched, so they are
+	// checked periodically instead.
+	var resyncPeriod time.Duration
+	if cacheOpts.IsRestricted() {
+		resyncPeriod = targetResyncPeriod
+	}
+
 	if err = (&controllers.MergeTargetReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: recorder,
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Recorder:  recorder,
+		APIReader: mgr.GetAPIReader(),
+
+		UncachedTargets: cacheOpts.IsRestricted(),
+		ResyncPeriod:    resyncPeriod,
+
+		EventRecorder: mgr.GetEventRecorderFor("cmmc"),
 	}).SetupWithManager(mgr, controller.Options{
 		MaxConcurrentReconciles: mergeTargetMaxConcurrentReconciles,
 	}); err != nil {
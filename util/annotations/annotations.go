@@ -2,12 +2,18 @@ package annotations
 
 import (
 	"context"
+	"reflect"
 	"strings"
 
 	"github.com/cashapp/cmmc/util"
+	"github.com/cashapp/cmmc/util/managedfields"
 	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
 const listSep = ","
@@ -60,6 +66,38 @@ func (a Annotation) ParseObjectName(o client.Object) (types.NamespacedName, bool
 	return namespacedName, true
 }
 
+// ParseObjectNames attempts to parse a list of object names from an annotation
+// on the given object, skipping any names that are invalid.
+func (a Annotation) ParseObjectNames(o client.Object) []types.NamespacedName {
+	n, ok := o.GetAnnotations()[string(a)]
+	if !ok || n == "" {
+		return nil
+	}
+
+	var names []types.NamespacedName
+	for _, val := range strings.Split(n, listSep) {
+		namespacedName, err := util.NamespacedName(val, "")
+		if err != nil {
+			continue
+		}
+
+		names = append(names, namespacedName)
+	}
+
+	return names
+}
+
+// Contains returns true if the list annotation contains val.
+func (a Annotation) Contains(o client.Object, val string) bool {
+	for _, v := range strings.Split(o.GetAnnotations()[string(a)], listSep) {
+		if v == val {
+			return true
+		}
+	}
+
+	return false
+}
+
 // UpdateFn is any function that mutates a string map.
 type UpdateFn func(in map[string]string)
 
@@ -158,3 +196,95 @@ func Apply(ctx context.Context, c client.Client, o client.Object, fns ...UpdateF
 
 	return errors.WithStack(c.Update(ctx, o))
 }
+
+// Patch applies UpdateFns to a resource with server-side apply (as
+// fieldOwner), and updates o with the result.
+//
+// Every annotation the fieldOwner applied before is applied again, since
+// leaving it out of the apply would remove it, so the fieldOwner must not
+// apply anything but annotations to the resource. Annotations that are removed
+// but owned by someone else (e.g. written with a merge patch) are taken over
+// first, so that they can be removed.
+//
+// The apply is conditional on the resourceVersion of o, and nothing is written
+// if the annotations don't change.
+func Patch(ctx context.Context, c client.Client, o client.Object, fieldOwner string, fns ...UpdateFn) error {
+	before := o.GetAnnotations()
+	after := map[string]string{}
+	for k, v := range before {
+		after[k] = v
+	}
+
+	for _, f := range fns {
+		f(after)
+	}
+
+	if len(before) == 0 && len(after) == 0 || reflect.DeepEqual(before, after) {
+		return nil
+	}
+
+	applied := map[string]string{}
+	for _, k := range managedfields.Fields(o, fieldOwner, metav1.ManagedFieldsOperationApply, "metadata", "annotations") {
+		if v, ok := before[k]; ok {
+			applied[k] = v
+		}
+	}
+
+	// removed annotations have to be owned to be removed by an apply.
+	var takeOver bool
+	for k, v := range before {
+		if _, ok := after[k]; !ok {
+			if _, owned := applied[k]; !owned {
+				applied[k], takeOver = v, true
+			}
+		}
+	}
+
+	if takeOver {
+		if err := applyAnnotations(ctx, c, o, fieldOwner, applied); err != nil {
+			return err
+		}
+	}
+
+	for k := range applied {
+		if _, ok := after[k]; !ok {
+			delete(applied, k)
+		}
+	}
+
+	for k, v := range after {
+		if before[k] != v {
+			applied[k] = v
+		}
+	}
+
+	return applyAnnotations(ctx, c, o, fieldOwner, applied)
+}
+
+// applyAnnotations server-side applies the annotations to the resource as
+// fieldOwner, and updates o with the result.
+func applyAnnotations(
+	ctx context.Context, c client.Client, o client.Object, fieldOwner string, annotations map[string]string,
+) error {
+	gvk, err := apiutil.GVKForObject(o, c.Scheme())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(o.GetName())
+	obj.SetNamespace(o.GetNamespace())
+	obj.SetResourceVersion(o.GetResourceVersion())
+	obj.SetAnnotations(annotations)
+
+	if err := c.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldOwner), client.ForceOwnership); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// the result replaces o entirely, fields it doesn't have are empty.
+	v := reflect.ValueOf(o).Elem()
+	v.Set(reflect.Zero(v.Type()))
+
+	return errors.WithStack(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), o))
+}
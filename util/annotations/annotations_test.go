@@ -5,6 +5,8 @@ import (
 
 	. "github.com/cashapp/cmmc/util/annotations"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestAddToList(t *testing.T) {
@@ -70,3 +72,21 @@ func assertUpdated(t *testing.T, in, expected map[string]string, fns ...UpdateFn
 
 	assert.Equal(t, expected, in)
 }
+
+func TestParseObjectNames(t *testing.T) {
+	var (
+		a  = Annotation("foo")
+		cm = &corev1.ConfigMap{}
+	)
+
+	assert.Empty(t, a.ParseObjectNames(cm))
+
+	cm.SetAnnotations(map[string]string{"foo": "default/a,,invalid/name/here,other/b"})
+	assert.Equal(t, []types.NamespacedName{
+		{Namespace: "default", Name: "a"},
+		{Namespace: "other", Name: "b"},
+	}, a.ParseObjectNames(cm))
+
+	assert.True(t, a.Contains(cm, "other/b"))
+	assert.False(t, a.Contains(cm, "other/c"))
+}
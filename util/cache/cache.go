@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+// Options scopes the informer cache of the controller manager.
+//
+// By default the manager caches every ConfigMap in the cluster, which
+// gets expensive on large clusters where cmmc only ever touches a handful
+// of them.
+type Options struct {
+	// Namespaces restricts all namespaced informers to these namespaces.
+	//
+	// Cluster scoped resources (Namespaces) are still cached globally.
+	Namespaces []string
+
+	// ConfigMapSelector restricts the ConfigMap informer to ConfigMaps
+	// matching the label selector.
+	ConfigMapSelector labels.Selector
+
+	// RevisionSelector restricts the ControllerRevision informer to the
+	// revisions matching the label selector, e.g. the ones kept by cmmc.
+	RevisionSelector labels.Selector
+}
+
+// NewOptions parses the comma separated list of namespaces and the ConfigMap
+// label selector into Options.
+func NewOptions(namespaces, configMapSelector string) (Options, error) {
+	var o Options
+
+	for _, ns := range strings.Split(namespaces, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			o.Namespaces = append(o.Namespaces, ns)
+		}
+	}
+
+	if configMapSelector != "" {
+		selector, err := labels.Parse(configMapSelector)
+		if err != nil {
+			return Options{}, errors.Wrapf(err, "invalid ConfigMap selector %q", configMapSelector)
+		}
+
+		o.ConfigMapSelector = selector
+	}
+
+	return o, nil
+}
+
+// IsRestricted is true if the cache does not contain every ConfigMap
+// in the cluster.
+//
+// When it is, ConfigMaps that do not match the restrictions (usually the
+// targets) have to be read through the API reader instead.
+func (o Options) IsRestricted() bool {
+	return len(o.Namespaces) > 0 || o.ConfigMapSelector != nil
+}
+
+// NewCacheFunc builds the cache.NewCacheFunc for the manager.
+func (o Options) NewCacheFunc() cache.NewCacheFunc {
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		selectors := cache.SelectorsByObject{}
+		if o.ConfigMapSelector != nil {
+			selectors[&corev1.ConfigMap{}] = cache.ObjectSelector{Label: o.ConfigMapSelector}
+		}
+
+		if o.RevisionSelector != nil {
+			selectors[&appsv1.ControllerRevision{}] = cache.ObjectSelector{Label: o.RevisionSelector}
+		}
+
+		if len(selectors) > 0 {
+			opts.SelectorsByObject = selectors
+		}
+
+		switch len(o.Namespaces) {
+		case 0:
+			return cache.New(config, opts) //nolint:wrapcheck
+		case 1:
+			opts.Namespace = o.Namespaces[0]
+			return cache.New(config, opts) //nolint:wrapcheck
+		default:
+			return cache.MultiNamespacedCacheBuilder(o.Namespaces)(config, opts) //nolint:wrapcheck
+		}
+	}
+}
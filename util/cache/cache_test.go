@@ -0,0 +1,30 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/cashapp/cmmc/util/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestNewOptions(t *testing.T) {
+	o, err := cache.NewOptions("", "")
+	require.NoError(t, err)
+	assert.False(t, o.IsRestricted())
+
+	o, err = cache.NewOptions(" kube-system, ,service-a", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kube-system", "service-a"}, o.Namespaces)
+	assert.True(t, o.IsRestricted())
+
+	o, err = cache.NewOptions("", "cmmc.k8s.cash.app/merge")
+	require.NoError(t, err)
+	assert.True(t, o.IsRestricted())
+	assert.True(t, o.ConfigMapSelector.Matches(labels.Set{"cmmc.k8s.cash.app/merge": "aws-auth"}))
+	assert.False(t, o.ConfigMapSelector.Matches(labels.Set{"app": "other"}))
+
+	_, err = cache.NewOptions("", "=nope=")
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package diff
+
+import (
+	"sort"
+	"strings"
+)
+
+// Lines returns a line based diff from before to after, with every line
+// prefixed by "-" (removed), "+" (added) or " " (unchanged).
+func Lines(before, after string) string {
+	a, b := splitLines(before), splitLines(after)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString(" " + a[i] + "\n")
+			i++
+			j++
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			sb.WriteString("-" + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+" + b[j] + "\n")
+			j++
+		}
+	}
+
+	return sb.String()
+}
+
+// ChangedKeys returns the sorted keys that were added, removed or changed
+// from before to after.
+func ChangedKeys(before, after map[string]string) []string {
+	var keys []string
+	for k, v := range before {
+		if w, ok := after[k]; !ok || v != w {
+			keys = append(keys, k)
+		}
+	}
+
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// Maps returns the diff of every changed key from before to after, each one
+// starting with a "--- <key>" and "+++ <key>" header.
+func Maps(before, after map[string]string) string {
+	var sb strings.Builder
+	for _, k := range ChangedKeys(before, after) {
+		sb.WriteString("--- " + k + "\n+++ " + k + "\n")
+		sb.WriteString(Lines(before[k], after[k]))
+	}
+
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,36 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/cashapp/cmmc/util/diff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	before := "- a\n- b\n- c\n"
+	after := "- a\n- c\n- d\n"
+
+	assert.Equal(t, " - a\n-- b\n - c\n+- d\n", diff.Lines(before, after))
+	assert.Equal(t, " - a\n - b\n", diff.Lines("- a\n- b", "- a\n- b\n"))
+	assert.Equal(t, "+- a\n", diff.Lines("", "- a\n"))
+	assert.Equal(t, "-- a\n", diff.Lines("- a\n", ""))
+	assert.Empty(t, diff.Lines("", ""))
+}
+
+func TestChangedKeys(t *testing.T) {
+	before := map[string]string{"same": "x", "changed": "x", "removed": "x"}
+	after := map[string]string{"same": "x", "changed": "y", "added": "y"}
+
+	assert.Equal(t, []string{"added", "changed", "removed"}, diff.ChangedKeys(before, after))
+	assert.Empty(t, diff.ChangedKeys(before, before))
+	assert.Empty(t, diff.ChangedKeys(nil, nil))
+}
+
+func TestMaps(t *testing.T) {
+	before := map[string]string{"mapRoles": "- a\n", "mapUsers": "- u\n"}
+	after := map[string]string{"mapRoles": "- a\n- b\n", "mapUsers": "- u\n"}
+
+	assert.Equal(t, "--- mapRoles\n+++ mapRoles\n - a\n+- b\n", diff.Maps(before, after))
+	assert.Empty(t, diff.Maps(before, before))
+}
@@ -0,0 +1,122 @@
+package entries
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Parse splits the value of a data key into its entries.
+//
+// If the value is a YAML list (e.g. mapRoles of aws-auth) its entries are the
+// items of the list, as canonical JSON, otherwise they are its non-empty lines.
+func Parse(value string) []string {
+	var items []interface{}
+	if err := yaml.Unmarshal([]byte(value), &items); err == nil && items != nil {
+		parsed := make([]string, 0, len(items))
+		for _, item := range items {
+			encoded, err := json.Marshal(item)
+			if err != nil {
+				return Lines(value)
+			}
+
+			parsed = append(parsed, string(encoded))
+		}
+
+		return parsed
+	}
+
+	return Lines(value)
+}
+
+// Removed returns the number of entries of before that aren't in after, and
+// the number of entries of before.
+func Removed(before, after string) (int, int) {
+	remaining := map[string]int{}
+	for _, e := range Parse(after) {
+		remaining[e]++
+	}
+
+	var (
+		previous = Parse(before)
+		removed  int
+	)
+
+	for _, e := range previous {
+		if remaining[e] > 0 {
+			remaining[e]--
+		} else {
+			removed++
+		}
+	}
+
+	return removed, len(previous)
+}
+
+// Contains returns true if one of the entries of value matches the required entry.
+//
+// A required YAML mapping (or a list with a single mapping) matches list items
+// with at least its fields, with the same values. Anything else matches equal
+// list items, or equal lines of values that aren't YAML lists.
+func Contains(value, required string) bool {
+	var want interface{}
+	if err := yaml.Unmarshal([]byte(required), &want); err != nil {
+		want = strings.TrimSpace(required)
+	} else if list, ok := want.([]interface{}); ok && len(list) == 1 {
+		want = list[0]
+	}
+
+	var items []interface{}
+	if err := yaml.Unmarshal([]byte(value), &items); err != nil || items == nil {
+		for _, line := range Lines(value) {
+			if line == strings.TrimSpace(required) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, item := range items {
+		if matches(item, want) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matches(item, want interface{}) bool {
+	wantFields, ok := want.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(item, want)
+	}
+
+	fields, ok := item.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	for k, v := range wantFields {
+		if !reflect.DeepEqual(fields[k], v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Lines returns the non-empty lines of the value, which are its entries if it
+// isn't a YAML list.
+func Lines(value string) []string {
+	var parsed []string
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			parsed = append(parsed, line)
+		}
+	}
+
+	return parsed
+}
@@ -0,0 +1,62 @@
+package entries_test
+
+import (
+	"testing"
+
+	"github.com/cashapp/cmmc/util/entries"
+	"github.com/stretchr/testify/assert"
+)
+
+const mapRoles = `- rolearn: arn:aws:iam::111122223333:role/node
+  username: system:node:{{EC2PrivateDNSName}}
+  groups: [system:bootstrappers, system:nodes]
+- rolearn: arn:aws:iam::111122223333:role/team-a
+  username: team-a
+- rolearn: arn:aws:iam::111122223333:role/team-b
+  username: team-b
+`
+
+func TestParse(t *testing.T) {
+	parsed := entries.Parse(mapRoles)
+	if assert.Len(t, parsed, 3) {
+		assert.Equal(t, `{"rolearn":"arn:aws:iam::111122223333:role/team-a","username":"team-a"}`, parsed[1])
+	}
+
+	assert.Equal(t, []string{"a", "b"}, entries.Parse("a\n\n  b\n"))
+	assert.Empty(t, entries.Parse(""))
+}
+
+func TestRemoved(t *testing.T) {
+	removed, total := entries.Removed(mapRoles, mapRoles)
+	assert.Equal(t, 0, removed)
+	assert.Equal(t, 3, total)
+
+	// formatting changes don't remove entries.
+	reformatted := `- {rolearn: "arn:aws:iam::111122223333:role/team-b", username: team-b}
+`
+	removed, total = entries.Removed(mapRoles, reformatted)
+	assert.Equal(t, 2, removed)
+	assert.Equal(t, 3, total)
+
+	removed, total = entries.Removed(mapRoles, "")
+	assert.Equal(t, 3, removed)
+	assert.Equal(t, 3, total)
+
+	removed, total = entries.Removed("", mapRoles)
+	assert.Equal(t, 0, removed)
+	assert.Equal(t, 0, total)
+}
+
+func TestContains(t *testing.T) {
+	assert.True(t, entries.Contains(mapRoles, "rolearn: arn:aws:iam::111122223333:role/node"))
+	assert.True(t, entries.Contains(mapRoles, `- rolearn: arn:aws:iam::111122223333:role/node
+  groups: [system:bootstrappers, system:nodes]`))
+	assert.False(t, entries.Contains(mapRoles, `rolearn: arn:aws:iam::111122223333:role/node
+groups: [system:nodes]`))
+	assert.False(t, entries.Contains(mapRoles, "rolearn: arn:aws:iam::111122223333:role/admin"))
+	assert.False(t, entries.Contains("", "rolearn: arn:aws:iam::111122223333:role/node"))
+
+	assert.True(t, entries.Contains("- a\n- b\n", "b"))
+	assert.True(t, entries.Contains("a\nb c\n", " b c "))
+	assert.False(t, entries.Contains("a\nb\n", "c"))
+}
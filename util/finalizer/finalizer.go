@@ -3,6 +3,7 @@ package finalizer
 import (
 	"context"
 
+	"github.com/cashapp/cmmc/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -42,7 +43,7 @@ func (f *finalizer) Execute(ctx Context, c Client, o Object) (bool, error) {
 
 	// If the finalizer has already been removed, we are good
 	// and we don't need to try to execute the finalizer.
-	if !containsString(o.GetFinalizers(), f.Name) {
+	if !util.ContainsString(o.GetFinalizers(), f.Name) {
 		return true, nil
 	}
 
@@ -88,15 +89,5 @@ func isCurrentlyBeingDeleted(o Object) bool {
 }
 
 func canAddFinalizer(o Object, name string) bool {
-	return !isCurrentlyBeingDeleted(o) && !containsString(o.GetFinalizers(), name)
-}
-
-func containsString(slice []string, s string) bool {
-	for _, item := range slice {
-		if item == s {
-			return true
-		}
-	}
-
-	return false
+	return !isCurrentlyBeingDeleted(o) && !util.ContainsString(o.GetFinalizers(), name)
 }
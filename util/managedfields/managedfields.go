@@ -0,0 +1,90 @@
+package managedfields
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Owners returns the managedFields entries of o that own the field at path.
+//
+// A path is the list of field names, e.g. ("data", "mapRoles") for the
+// mapRoles key of a ConfigMap.
+func Owners(o metav1.Object, path ...string) []metav1.ManagedFieldsEntry {
+	var owners []metav1.ManagedFieldsEntry
+	for _, entry := range o.GetManagedFields() {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+
+		if containsPath(fields, path) {
+			owners = append(owners, entry)
+		}
+	}
+
+	return owners
+}
+
+// IsOwnedBy returns true if the manager owns the field at path with the given operation.
+func IsOwnedBy(
+	o metav1.Object, manager string, operation metav1.ManagedFieldsOperationType, path ...string,
+) bool {
+	for _, entry := range Owners(o, path...) {
+		if entry.Manager == manager && entry.Operation == operation {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Fields returns the names of the fields under path that the manager owns with
+// the given operation, e.g. the data keys of a ConfigMap for path ("data").
+func Fields(
+	o metav1.Object, manager string, operation metav1.ManagedFieldsOperationType, path ...string,
+) []string {
+	var names []string
+	for _, entry := range o.GetManagedFields() {
+		if entry.Manager != manager || entry.Operation != operation || entry.FieldsV1 == nil {
+			continue
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+
+		for _, p := range path {
+			fields, _ = fields["f:"+p].(map[string]interface{})
+		}
+
+		for k := range fields {
+			if strings.HasPrefix(k, "f:") {
+				names = append(names, strings.TrimPrefix(k, "f:"))
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func containsPath(fields map[string]interface{}, path []string) bool {
+	for _, p := range path {
+		next, ok := fields["f:"+p].(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		fields = next
+	}
+
+	return true
+}
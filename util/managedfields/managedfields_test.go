@@ -0,0 +1,58 @@
+package managedfields_test
+
+import (
+	"testing"
+
+	"github.com/cashapp/cmmc/util/managedfields"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOwners(t *testing.T) {
+	cm := &corev1.ConfigMap{}
+	cm.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{
+			Manager:   "cmmc",
+			Operation: metav1.ManagedFieldsOperationApply,
+			FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:mapRoles":{}}}`)},
+		},
+		{
+			Manager:   "kubectl-edit",
+			Operation: metav1.ManagedFieldsOperationUpdate,
+			FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:data":{".":{},"f:mapUsers":{}}}`)},
+		},
+	})
+
+	owners := managedfields.Owners(cm, "data", "mapUsers")
+	if assert.Len(t, owners, 1) {
+		assert.Equal(t, "kubectl-edit", owners[0].Manager)
+	}
+
+	assert.Len(t, managedfields.Owners(cm, "data"), 2)
+	assert.Empty(t, managedfields.Owners(cm, "data", "missing"))
+
+	assert.True(t, managedfields.IsOwnedBy(cm, "cmmc", metav1.ManagedFieldsOperationApply, "data", "mapRoles"))
+	assert.False(t, managedfields.IsOwnedBy(cm, "cmmc", metav1.ManagedFieldsOperationUpdate, "data", "mapRoles"))
+	assert.False(t, managedfields.IsOwnedBy(cm, "cmmc", metav1.ManagedFieldsOperationApply, "data", "mapUsers"))
+}
+
+func TestFields(t *testing.T) {
+	cm := &corev1.ConfigMap{}
+	cm.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{
+			Manager:   "cmmc",
+			Operation: metav1.ManagedFieldsOperationApply,
+			FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:mapUsers":{},"f:mapRoles":{}}}`)},
+		},
+		{
+			Manager:   "cmmc",
+			Operation: metav1.ManagedFieldsOperationUpdate,
+			FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:data":{".":{},"f:other":{}}}`)},
+		},
+	})
+
+	assert.Equal(t, []string{"mapRoles", "mapUsers"}, managedfields.Fields(cm, "cmmc", metav1.ManagedFieldsOperationApply, "data"))
+	assert.Equal(t, []string{"other"}, managedfields.Fields(cm, "cmmc", metav1.ManagedFieldsOperationUpdate, "data"))
+	assert.Empty(t, managedfields.Fields(cm, "cmmc", metav1.ManagedFieldsOperationApply, "metadata"))
+}
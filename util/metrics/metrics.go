@@ -11,6 +11,9 @@ import (
 type Recorder struct {
 	sourceGauge    *prometheus.GaugeVec
 	conditionGauge *prometheus.GaugeVec
+	driftCounter   *prometheus.CounterVec
+	schemaGauge    *prometheus.GaugeVec
+	cacheCounter   *prometheus.CounterVec
 }
 
 // NewRecorder creates a Recorder for cmmc.
@@ -30,6 +33,27 @@ func NewRecorder() *Recorder {
 			},
 			[]string{"kind", "namespace", "name", "type", "status"},
 		),
+		driftCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cmmc_resource_drift_total",
+				Help: "Number of out-of-band modifications of managed keys, by the field manager modifying them.",
+			},
+			[]string{"kind", "namespace", "name", "key", "manager"},
+		),
+		schemaGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "cmmc_resource_schema_violations",
+				Help: "Number of schema violations of a key, when the schema is not enforced.",
+			},
+			[]string{"kind", "namespace", "name", "key", "enforcement"},
+		),
+		cacheCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "cmmc_schema_cache_lookups_total",
+				Help: "Number of lookups of compiled schemas in the schema cache, by result (hit or miss).",
+			},
+			[]string{"result"},
+		),
 	}
 }
 
@@ -38,9 +62,39 @@ func (r *Recorder) Collectors() []prometheus.Collector {
 	return []prometheus.Collector{
 		r.sourceGauge,
 		r.conditionGauge,
+		r.driftCounter,
+		r.schemaGauge,
+		r.cacheCounter,
 	}
 }
 
+// RecordDrift records an out-of-band modification of a managed key by manager.
+func (r *Recorder) RecordDrift(o client.Object, key, manager string) {
+	r.driftCounter.With(resourceLables(o, prometheus.Labels{"key": key, "manager": manager})).Inc()
+}
+
+// RecordSchemaViolations records how many schema violations a key has, with a
+// schema enforcement that doesn't block the key.
+func (r *Recorder) RecordSchemaViolations(o client.Object, key, enforcement string, count int) {
+	r.schemaGauge.With(resourceLables(o, prometheus.Labels{"key": key, "enforcement": enforcement})).Set(float64(count))
+}
+
+// ForgetSchemaViolations removes the schema violations of every key of the
+// object, e.g. once it's deleted or its keys are enforced again.
+func (r *Recorder) ForgetSchemaViolations(o client.Object) {
+	r.schemaGauge.DeletePartialMatch(resourceLables(o))
+}
+
+// RecordSchemaCacheLookup records a lookup in the schema cache.
+func (r *Recorder) RecordSchemaCacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	r.cacheCounter.With(prometheus.Labels{"result": result}).Inc()
+}
+
 // RecordNumSources records how many sources a given object has.
 //
 // This can be used for both MergeSource and MergeTarget resources.
@@ -1,6 +1,8 @@
 package util
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -64,3 +66,20 @@ func ObjectNamespacedName(o client.Object) types.NamespacedName {
 func ObjectResourceName(o client.Object) string {
 	return ObjectNamespacedName(o).String()
 }
+
+// Digest returns the hex encoded sha256 digest of data.
+func Digest(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// ContainsString returns true if values contains v.
+func ContainsString(values []string, v string) bool {
+	for _, val := range values {
+		if val == v {
+			return true
+		}
+	}
+
+	return false
+}
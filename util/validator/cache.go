@@ -0,0 +1,109 @@
+package validator
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/cashapp/cmmc/util"
+)
+
+// DefaultCacheSize is the number of compiled schemas cached by default.
+const DefaultCacheSize = 256
+
+// SchemaCache is a bounded cache of compiled schemas, keyed by the digest of
+// the schema (and its engine), which evicts the least recently used schemas first.
+//
+// It is safe for concurrent use.
+type SchemaCache struct {
+	mu      sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[string]*list.Element
+
+	// observe (if set) is called on every lookup, with whether it was a hit.
+	observe func(hit bool)
+}
+
+type cachedSchema struct {
+	digest string
+	schema Schema
+}
+
+// NewSchemaCache creates a cache of (at most) size compiled schemas, calling
+// observe (if not nil) on every lookup.
+func NewSchemaCache(size int, observe func(hit bool)) *SchemaCache {
+	return &SchemaCache{
+		size:    size,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+		observe: observe,
+	}
+}
+
+// Compile returns the schema compiled with the engine, compiling it if it isn't cached.
+func (c *SchemaCache) Compile(engine Engine, jsonSchema string) (Schema, error) {
+	digest := util.Digest(string(engine) + "\n" + jsonSchema)
+
+	c.mu.Lock()
+	e, hit := c.entries[digest]
+	if hit {
+		c.lru.MoveToFront(e)
+	}
+	c.mu.Unlock()
+
+	if c.observe != nil {
+		c.observe(hit)
+	}
+
+	if hit {
+		return e.Value.(*cachedSchema).schema, nil //nolint:forcetypeassert
+	}
+
+	// compiling is the expensive part, it is done without holding the lock.
+	schema, err := compileSchema(engine, jsonSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[digest]; !ok && c.size > 0 {
+		c.entries[digest] = c.lru.PushFront(&cachedSchema{digest: digest, schema: schema})
+		for c.lru.Len() > c.size {
+			oldest := c.lru.Remove(c.lru.Back()).(*cachedSchema) //nolint:forcetypeassert
+			delete(c.entries, oldest.digest)
+		}
+	}
+
+	return schema, nil
+}
+
+// Len is the number of cached schemas.
+func (c *SchemaCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+var (
+	cacheMu      sync.RWMutex
+	defaultCache = NewSchemaCache(DefaultCacheSize, nil)
+)
+
+// SetCache replaces the cache of compiled schemas used by all the validators.
+func SetCache(c *SchemaCache) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	defaultCache = c
+}
+
+func compile(engine Engine, jsonSchema string) (Schema, error) {
+	cacheMu.RLock()
+	c := defaultCache
+	cacheMu.RUnlock()
+
+	return c.Compile(engine, jsonSchema)
+}
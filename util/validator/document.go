@@ -0,0 +1,180 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// Document is YAML (or JSON) data parsed once, so that it can be validated
+// against several schemas, and merged with other documents, without being
+// parsed again.
+type Document struct {
+	json []byte
+
+	// items are the items of the document, if it is a list.
+	items []json.RawMessage
+	list  bool
+
+	// block is true if the data is a block sequence starting at the first
+	// column, which can be concatenated with other ones.
+	block bool
+
+	// open is true if the data doesn't end with a new line, in which case
+	// the data following it continues its last line.
+	open bool
+}
+
+// Parse parses YAML (or JSON) data.
+func Parse(data string) (*Document, error) {
+	j, err := yaml.YAMLToJSON([]byte(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse yaml to json")
+	}
+
+	doc := &Document{json: j, open: data != "" && !strings.HasSuffix(data, "\n")}
+	if bytes.HasPrefix(j, []byte("[")) {
+		if err := json.Unmarshal(j, &doc.items); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		doc.list = true
+		doc.block = isBlockSequence(data)
+	}
+
+	return doc, nil
+}
+
+// String returns the document of a string, e.g. data that isn't parsed.
+func String(s string) *Document {
+	j, _ := json.Marshal(s) // strings always encode.
+	return &Document{json: j}
+}
+
+// Object returns the document of an object whose properties are the given
+// documents, without parsing them again.
+func Object(properties map[string]*Document) *Document {
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		name, _ := json.Marshal(k) // strings always encode.
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(properties[k].json)
+	}
+	b.WriteByte('}')
+
+	return &Document{json: b.Bytes()}
+}
+
+// isBlockSequence returns true if the first line of the data that isn't empty
+// (or a comment) is the item of a block sequence, starting at the first column.
+func isBlockSequence(data string) bool {
+	for _, line := range strings.Split(data, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		return line == "-" || strings.HasPrefix(line, "- ")
+	}
+
+	return false
+}
+
+// Items returns the items of the document, as documents, if it is a list.
+func (d *Document) Items() ([]*Document, bool) {
+	if !d.list {
+		return nil, false
+	}
+
+	items := make([]*Document, 0, len(d.items))
+	for _, item := range d.items {
+		items = append(items, &Document{json: item})
+	}
+
+	return items, true
+}
+
+// Len returns the number of items of the document, if it is a list.
+func (d *Document) Len() (int, bool) {
+	return len(d.items), d.list
+}
+
+// String returns the document as compact JSON, in which the keys of objects
+// are sorted, so that equal documents have the same string.
+func (d *Document) String() string {
+	return string(d.json)
+}
+
+// Value decodes the document, with its numbers as json.Number.
+func (d *Document) Value() (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(d.json))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return v, nil
+}
+
+// IsEmpty is true for empty (or comment only) data.
+func (d *Document) IsEmpty() bool {
+	return string(d.json) == "null"
+}
+
+// Concat returns the document of the concatenated data of the documents (in
+// order), without parsing it again. This is only possible if the non-empty
+// documents are YAML block sequences starting at the first column, each ending
+// with a new line (but the last one), otherwise ok is false and the
+// concatenated data needs to be parsed.
+func Concat(docs ...*Document) (doc *Document, ok bool) {
+	var (
+		items []json.RawMessage
+		list  bool
+		open  bool
+	)
+
+	for _, d := range docs {
+		if d.IsEmpty() && !d.open {
+			continue
+		} else if !d.block || open {
+			return nil, false
+		}
+
+		items = append(items, d.items...)
+		list, open = true, d.open
+	}
+
+	if !list {
+		return &Document{json: []byte("null")}, true
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for i, item := range items {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(item)
+	}
+	b.WriteByte(']')
+
+	return &Document{json: b.Bytes(), items: items, list: true, open: open}, true
+}
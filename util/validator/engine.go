@@ -0,0 +1,170 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Engine is the JSON schema implementation (and draft of the specification)
+// schemas are compiled with.
+type Engine string
+
+const (
+	// EngineDraft07 implements draft-07, it is the default engine.
+	EngineDraft07 Engine = "draft-07"
+
+	// Engine201909 implements draft 2019-09.
+	Engine201909 Engine = "2019-09"
+
+	// Engine202012 implements draft 2020-12.
+	Engine202012 Engine = "2020-12"
+)
+
+// Validator validates parsed data against JSON schemas.
+type Validator interface {
+	// Validate validates the document against the schema, it returns an
+	// *InvalidContentError if the document doesn't validate.
+	Validate(jsonSchema string, doc *Document) error
+}
+
+// Schema is a compiled JSON schema.
+type Schema interface {
+	// Validate validates the document, it returns an *InvalidContentError if
+	// the document doesn't validate.
+	Validate(doc *Document) error
+}
+
+// For returns the validator of the engine (the default one if it is empty).
+//
+// Compiled schemas are cached (see SetCache).
+func For(engine Engine) (Validator, error) {
+	switch engine {
+	case "", EngineDraft07:
+		return engineValidator(EngineDraft07), nil
+	case Engine201909, Engine202012:
+		return engineValidator(engine), nil
+	default:
+		return nil, errors.Errorf("unknown schema engine %q", engine)
+	}
+}
+
+type engineValidator Engine
+
+func (v engineValidator) Validate(jsonSchema string, doc *Document) error {
+	schema, err := compile(Engine(v), jsonSchema)
+	if err != nil {
+		return err
+	}
+
+	return schema.Validate(doc)
+}
+
+// compileSchema compiles the schema with the engine, without caching it.
+func compileSchema(engine Engine, jsonSchema string) (Schema, error) {
+	if engine == EngineDraft07 {
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(jsonSchema))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create schema")
+		}
+
+		return draft07Schema{schema}, nil
+	}
+
+	const url = "schema.json"
+
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	if engine == Engine201909 {
+		c.Draft = jsonschema.Draft2019
+	}
+
+	// schemas can't refer to anything but themselves (e.g. local files).
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, errors.Errorf("loading %s is not supported", s)
+	}
+
+	c.AssertFormat = true
+	for name, check := range registeredFormats() {
+		check := check
+		c.Formats[name] = func(v interface{}) bool {
+			s, ok := v.(string)
+			return !ok || check(s)
+		}
+	}
+
+	if err := c.AddResource(url, strings.NewReader(jsonSchema)); err != nil {
+		return nil, errors.Wrap(err, "failed to create schema")
+	}
+
+	schema, err := c.Compile(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create schema")
+	}
+
+	return draft2019Schema{schema}, nil
+}
+
+// draft07Schema is a schema compiled by gojsonschema.
+type draft07Schema struct {
+	schema *gojsonschema.Schema
+}
+
+func (s draft07Schema) Validate(doc *Document) error {
+	result, err := s.schema.Validate(gojsonschema.NewBytesLoader(doc.json))
+	if err != nil {
+		return errors.Wrap(err, "validation error")
+	}
+
+	if result.Valid() {
+		return nil
+	}
+
+	return InvalidContentErr(result.Errors())
+}
+
+// draft2019Schema is a schema compiled by jsonschema (for drafts 2019-09 and later).
+type draft2019Schema struct {
+	schema *jsonschema.Schema
+}
+
+func (s draft2019Schema) Validate(doc *Document) error {
+	d := json.NewDecoder(bytes.NewReader(doc.json))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return errors.Wrap(err, "validation error")
+	}
+
+	err := s.schema.Validate(v)
+
+	var invalid *jsonschema.ValidationError
+	if !errors.As(err, &invalid) {
+		return errors.Wrap(err, "validation error")
+	}
+
+	return &InvalidContentError{Violations: leafViolations(invalid, nil)}
+}
+
+// leafViolations flattens the causes of the validation error.
+func leafViolations(err *jsonschema.ValidationError, violations []Violation) []Violation {
+	if len(err.Causes) == 0 {
+		return append(violations, Violation{
+			Pointer: err.InstanceLocation,
+			Rule:    err.KeywordLocation[strings.LastIndex(err.KeywordLocation, "/")+1:],
+			Message: err.Message,
+		})
+	}
+
+	for _, cause := range err.Causes {
+		violations = leafViolations(cause, violations)
+	}
+
+	return violations
+}
@@ -0,0 +1,61 @@
+package validator
+
+import (
+	"net"
+	"regexp"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// FormatChecker checks that a string value has a format, for the "format"
+// keyword of JSON schemas.
+type FormatChecker func(string) bool
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]FormatChecker{}
+
+	awsARN = regexp.MustCompile(`^arn:aws(-cn|-us-gov|-iso|-iso-b)?:[a-z0-9-]+:[a-z0-9-]*:(\d{12}|aws)?:.+$`)
+)
+
+func init() {
+	RegisterFormat("aws-arn", awsARN.MatchString)
+	RegisterFormat("dns-1123-label", func(s string) bool { return len(validation.IsDNS1123Label(s)) == 0 })
+	RegisterFormat("cidr", func(s string) bool {
+		_, _, err := net.ParseCIDR(s)
+		return err == nil
+	})
+}
+
+// RegisterFormat registers a format checker with all the engines.
+//
+// Schemas compiled (and cached) before it is registered don't check it.
+func RegisterFormat(name string, check FormatChecker) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	formats[name] = check
+	gojsonschema.FormatCheckers.Add(name, gojsonschemaFormat(check))
+}
+
+func registeredFormats() map[string]FormatChecker {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	registered := make(map[string]FormatChecker, len(formats))
+	for name, check := range formats {
+		registered[name] = check
+	}
+
+	return registered
+}
+
+// gojsonschemaFormat adapts a FormatChecker to gojsonschema.
+type gojsonschemaFormat FormatChecker
+
+func (f gojsonschemaFormat) IsFormat(input interface{}) bool {
+	s, ok := input.(string)
+	return !ok || f(s)
+}
@@ -2,43 +2,86 @@ package validator
 
 import (
 	"fmt"
+	"strings"
 
-	"github.com/pkg/errors"
 	"github.com/xeipuuv/gojsonschema"
-	"sigs.k8s.io/yaml"
 )
 
+// Validate parses the YAML (or JSON) data, and validates it against the schema.
 func Validate(jsonSchema string, data string) error {
-	json, err := yaml.YAMLToJSON([]byte(data))
+	doc, err := Parse(data)
 	if err != nil {
-		return errors.Wrap(err, "failed to parse yaml to json")
+		return err
 	}
 
-	schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(jsonSchema))
-	if err != nil {
-		return errors.Wrap(err, "failed to create schema")
-	}
+	return ValidateDocument(jsonSchema, doc)
+}
 
-	result, err := schema.Validate(gojsonschema.NewBytesLoader(json))
-	if err != nil {
-		return errors.Wrap(err, "validation error")
-	}
+// ValidateDocument validates parsed data against the schema, with the default
+// engine (draft-07).
+func ValidateDocument(jsonSchema string, doc *Document) error {
+	return engineValidator(EngineDraft07).Validate(jsonSchema, doc)
+}
 
-	if result.Valid() {
-		return nil
-	}
+// Violation is a schema rule the content failed.
+type Violation struct {
+	// Pointer is the JSON pointer of the invalid value, e.g. "/0/rolearn".
+	Pointer string
+
+	// Rule is the schema rule that failed, e.g. "required" or "pattern".
+	Rule string
 
-	return InvalidContentErr(result.Errors())
+	Message string
 }
 
 type InvalidContentError struct {
-	Errs []gojsonschema.ResultError
+	Errs       []gojsonschema.ResultError
+	Violations []Violation
+
+	// Key, MergeSource and ConfigMap attribute the content to a data key,
+	// and to the source it was contributed by, when they are known.
+	Key         string
+	MergeSource string
+	ConfigMap   string
 }
 
 func (e *InvalidContentError) Error() string {
-	return fmt.Sprintf("failed validation with errors: %s", e.Errs)
+	if e.Errs != nil {
+		return fmt.Sprintf("failed validation with errors: %s", e.Errs)
+	}
+
+	errs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		field := gojsonschema.STRING_ROOT_SCHEMA_PROPERTY + v.Pointer
+		errs = append(errs, fmt.Sprintf("%s: %s", field, v.Message))
+	}
+
+	return fmt.Sprintf("failed validation with errors: %s", errs)
 }
 
 func InvalidContentErr(errs []gojsonschema.ResultError) error {
-	return &InvalidContentError{Errs: errs}
+	violations := make([]Violation, 0, len(errs))
+	for _, err := range errs {
+		violations = append(violations, Violation{
+			Pointer: pointer(err.Field()),
+			Rule:    err.Type(),
+			Message: err.Description(),
+		})
+	}
+
+	return &InvalidContentError{Errs: errs, Violations: violations}
+}
+
+// pointer converts a gojsonschema field path (e.g. "0.rolearn") to a JSON pointer.
+func pointer(field string) string {
+	if field == "" || field == gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, p := range strings.Split(strings.TrimPrefix(field, gojsonschema.STRING_ROOT_SCHEMA_PROPERTY+"."), ".") {
+		sb.WriteString("/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(p))
+	}
+
+	return sb.String()
 }
@@ -1,6 +1,9 @@
 package validator_test
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/cashapp/cmmc/util/validator"
@@ -57,3 +60,255 @@ func TestValidateAWSAuth(t *testing.T) {
 		t.Errorf("expected this to fail")
 	}
 }
+
+func TestValidateViolations(t *testing.T) {
+	err := validator.Validate(awsAuthMapRolesSchema, `
+- rolearn: arn:aws:iam::111122223333:role/ok
+  username: ok
+  groups: []
+- rolearn: banana
+  username: banana
+  groups: []
+`)
+
+	var invalid *validator.InvalidContentError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected an InvalidContentError, got %v", err)
+	}
+
+	if len(invalid.Violations) != 1 {
+		t.Fatalf("expected a single violation, got %v", invalid.Violations)
+	}
+
+	if v := invalid.Violations[0]; v.Pointer != "/1/rolearn" || v.Rule != "pattern" {
+		t.Errorf("unexpected violation %+v", v)
+	}
+}
+
+func TestSchemaCache(t *testing.T) {
+	var hits, misses int
+	c := validator.NewSchemaCache(1, func(hit bool) {
+		if hit {
+			hits++
+		} else {
+			misses++
+		}
+	})
+
+	for _, tc := range []struct {
+		engine validator.Engine
+		schema string
+	}{
+		{validator.EngineDraft07, `{"type":"string"}`},
+		{validator.EngineDraft07, `{"type":"string"}`},
+		{validator.EngineDraft07, `{"type":"array"}`},
+		{validator.EngineDraft07, `{"type":"string"}`},
+		{validator.Engine202012, `{"type":"string"}`},
+	} {
+		if _, err := c.Compile(tc.engine, tc.schema); err != nil {
+			t.Fatalf("unexpected failure %s", err)
+		}
+	}
+
+	if hits != 1 || misses != 4 || c.Len() != 1 {
+		t.Errorf("expected 1 hit, 4 misses and 1 cached schema, got %d, %d and %d", hits, misses, c.Len())
+	}
+
+	if _, err := c.Compile(validator.EngineDraft07, `{"type"nana"}`); err == nil {
+		t.Error("expected failure but succeeded")
+	}
+}
+
+func TestEngines(t *testing.T) {
+	const defs = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "array",
+  "items": { "$ref": "#/$defs/role" },
+  "$defs": {
+    "role": {
+      "type": "object",
+      "required": ["rolearn"],
+      "properties": { "rolearn": { "type": "string", "format": "aws-arn" } },
+      "unevaluatedProperties": false
+    }
+  }
+}`
+
+	for _, tc := range []struct {
+		name    string
+		engine  validator.Engine
+		data    string
+		pointer string
+		rule    string
+	}{
+		{"valid", validator.Engine202012, "- rolearn: arn:aws:iam::111122223333:role/a\n", "", ""},
+		{"format", validator.Engine202012, "- rolearn: a\n", "/0/rolearn", "format"},
+		{"unevaluated", validator.Engine202012, "- rolearn: arn:aws:iam::111122223333:role/a\n  extra: b\n", "/0/extra", "unevaluatedProperties"},
+		{"2019-09", validator.Engine201909, "- rolearn: a\n", "/0/rolearn", "format"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := validator.For(tc.engine)
+			if err != nil {
+				t.Fatalf("unexpected failure %s", err)
+			}
+
+			doc, err := validator.Parse(tc.data)
+			if err != nil {
+				t.Fatalf("unexpected failure %s", err)
+			}
+
+			err = v.Validate(defs, doc)
+			if tc.rule == "" {
+				if err != nil {
+					t.Fatalf("unexpected failure %s", err)
+				}
+				return
+			}
+
+			var invalid *validator.InvalidContentError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("expected an InvalidContentError, got %v", err)
+			}
+
+			if len(invalid.Violations) != 1 {
+				t.Fatalf("expected a single violation, got %v", invalid.Violations)
+			}
+
+			if v := invalid.Violations[0]; v.Pointer != tc.pointer || v.Rule != tc.rule {
+				t.Errorf("unexpected violation %+v", v)
+			}
+		})
+	}
+
+	if _, err := validator.For("draft-04"); err == nil {
+		t.Error("expected failure but succeeded")
+	}
+}
+
+func TestFormats(t *testing.T) {
+	for _, tc := range []struct {
+		format string
+		valid  string
+		bad    string
+	}{
+		{"aws-arn", "arn:aws:iam::111122223333:role/a", "arn:nope"},
+		{"dns-1123-label", "my-app", "My_App"},
+		{"cidr", "10.0.0.0/16", "10.0.0.0"},
+	} {
+		schema := fmt.Sprintf(`{"type":"string","format":%q}`, tc.format)
+		for _, engine := range []validator.Engine{validator.EngineDraft07, validator.Engine201909, validator.Engine202012} {
+			v, err := validator.For(engine)
+			if err != nil {
+				t.Fatalf("unexpected failure %s", err)
+			}
+
+			for data, valid := range map[string]bool{tc.valid: true, tc.bad: false} {
+				doc, err := validator.Parse(fmt.Sprintf("%q", data))
+				if err != nil {
+					t.Fatalf("unexpected failure %s", err)
+				}
+
+				if err := v.Validate(schema, doc); (err == nil) != valid {
+					t.Errorf("%s: expected %q to be valid=%t with %s, got %v", engine, data, valid, tc.format, err)
+				}
+			}
+		}
+	}
+}
+
+func TestConcat(t *testing.T) {
+	parse := func(data string) *validator.Document {
+		doc, err := validator.Parse(data)
+		if err != nil {
+			t.Fatalf("unexpected failure %s", err)
+		}
+
+		return doc
+	}
+
+	for _, tc := range []struct {
+		name  string
+		parts []string
+		ok    bool
+	}{
+		{"lists", []string{"# roles\n- rolearn: a\n", "", "- rolearn: b\n  groups: [c]\n", "- d"}, true},
+		{"empty", []string{"", "# nothing\n"}, true},
+		{"not a list", []string{"- a\n", "b: c\n"}, false},
+		{"flow sequence", []string{"- a\n", "[b]\n"}, false},
+		{"indented", []string{"- a\n", "  - b\n"}, false},
+		{"no new line", []string{"- a", "- b\n"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				docs = make([]*validator.Document, 0, len(tc.parts))
+				data string
+			)
+
+			for _, p := range tc.parts {
+				docs = append(docs, parse(p))
+				data += p
+			}
+
+			doc, ok := validator.Concat(docs...)
+			if ok != tc.ok {
+				t.Fatalf("expected ok to be %t", tc.ok)
+			} else if !ok {
+				return
+			}
+
+			// both documents validate the same.
+			for _, schema := range []string{`{"type":"array","maxItems":3}`, `{"type":"null"}`} {
+				if (validator.ValidateDocument(schema, doc) == nil) != (validator.Validate(schema, data) == nil) {
+					t.Errorf("concatenated document and data validate differently against %s", schema)
+				}
+			}
+		})
+	}
+}
+
+// awsAuthFixture is a large mapRoles, contributed by many sources.
+func awsAuthFixture(sources, rolesPerSource int) []string {
+	parts := make([]string, sources)
+	for s := range parts {
+		for r := 0; r < rolesPerSource; r++ {
+			parts[s] += fmt.Sprintf(
+				"- rolearn: arn:aws:iam::111122223333:role/team-%d-role-%d\n  username: team-%d-%d\n  groups:\n  - system:masters\n",
+				s, r, s, r,
+			)
+		}
+	}
+
+	return parts
+}
+
+func BenchmarkMerged(b *testing.B) {
+	parts := awsAuthFixture(100, 20)
+	data := strings.Join(parts, "")
+
+	docs := make([]*validator.Document, 0, len(parts))
+	for _, p := range parts {
+		doc, err := validator.Parse(p)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	b.Run("parse", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := validator.Parse(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("concat", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, ok := validator.Concat(docs...); !ok {
+				b.Fatal("not concatenated")
+			}
+		}
+	})
+}